@@ -0,0 +1,351 @@
+package fox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Predefined formats of the AccessLog middleware, any other format is parsed as a text/template
+// executed with LogFormatterParams, e.g. `{{.Method}} {{.Route}} {{.StatusCode}} {{.Latency}}`.
+const (
+	// LogFormatDefault is a human readable line, colorized in debug mode:
+	// [FOX] 2006/01/02 - 15:04:05 | 200 |  1.2ms | 127.0.0.1 | GET "/users/1" /users/:id 4a1f...
+	LogFormatDefault = "default"
+	// LogFormatCommon is the Common Log Format of Apache and Nginx:
+	// 127.0.0.1 - - [02/Jan/2006:15:04:05 -0700] "GET /users/1 HTTP/1.1" 200 42
+	LogFormatCommon = "common"
+	// LogFormatCombined is LogFormatCommon followed by the referer and the user agent.
+	LogFormatCombined = "combined"
+	// LogFormatJSON writes every request as a JSON object per line.
+	LogFormatJSON = "json"
+)
+
+const (
+	green   = "\033[97;42m"
+	white   = "\033[90;47m"
+	yellow  = "\033[90;43m"
+	red     = "\033[97;41m"
+	blue    = "\033[97;44m"
+	magenta = "\033[97;45m"
+	cyan    = "\033[97;46m"
+	reset   = "\033[0m"
+)
+
+// AccessLogConfig defines the config for AccessLog middleware.
+type AccessLogConfig struct {
+	// Format of the log lines, LogFormatDefault by default, see the LogFormat constants.
+	Format string
+
+	// Optional. Formatter overrides Format with a function.
+	Formatter LogFormatter
+
+	// Output is a writer where logs are written. Optional. Default value is fox.DefaultWriter.
+	Output io.Writer
+
+	// SkipPaths is an url path array which logs are not written, e.g. health checks.
+	SkipPaths []string
+
+	// Optional. Skip reports whether the log of the request is not written.
+	Skip func(c *Context) bool
+}
+
+// LogFormatter gives the signature of the formatter function passed to AccessLogWithConfig.
+type LogFormatter func(params LogFormatterParams) string
+
+// LogFormatterParams is the structure any formatter will be handed when time to log comes.
+type LogFormatterParams struct {
+	Request *http.Request
+
+	// TimeStamp shows the time after the server returns a response.
+	TimeStamp time.Time
+	// StatusCode is HTTP response code.
+	StatusCode int
+	// Latency is how much time the server cost to process a certain request.
+	Latency time.Duration
+	// ClientIP equals Context's ClientIP method.
+	ClientIP string
+	// Method is the HTTP method given to the request.
+	Method string
+	// Path is a path the client requests.
+	Path string
+	// Route is the pattern of the matched route, e.g. /users/:id, empty if no route matched.
+	Route string
+	// RequestID is the ID of the request, see RequestID middleware.
+	RequestID string
+	// BodySize is the size of the Response Body.
+	BodySize int
+	// Keys are the keys set on the request's context.
+	Keys map[string]any
+
+	// isTerm shows whether the output descriptor refers to a terminal.
+	isTerm bool
+}
+
+// StatusCodeColor is the ANSI color for appropriately logging http status code to a terminal.
+func (p *LogFormatterParams) StatusCodeColor() string {
+	code := p.StatusCode
+
+	switch {
+	case code >= http.StatusContinue && code < http.StatusOK:
+		return white
+	case code >= http.StatusOK && code < http.StatusMultipleChoices:
+		return green
+	case code >= http.StatusMultipleChoices && code < http.StatusBadRequest:
+		return white
+	case code >= http.StatusBadRequest && code < http.StatusInternalServerError:
+		return yellow
+	default:
+		return red
+	}
+}
+
+// MethodColor is the ANSI color for appropriately logging http method to a terminal.
+func (p *LogFormatterParams) MethodColor() string {
+	switch p.Method {
+	case http.MethodGet:
+		return blue
+	case http.MethodPost:
+		return cyan
+	case http.MethodPut:
+		return yellow
+	case http.MethodDelete:
+		return red
+	case http.MethodPatch:
+		return green
+	case http.MethodHead:
+		return magenta
+	case http.MethodOptions:
+		return white
+	default:
+		return reset
+	}
+}
+
+// ResetColor resets all escape attributes.
+func (p *LogFormatterParams) ResetColor() string {
+	return reset
+}
+
+// IsOutputColor indicates whether can colors be outputted to the log,
+// the output is colorized in debug mode if it is a terminal.
+func (p *LogFormatterParams) IsOutputColor() bool {
+	return p.isTerm && IsDebugging()
+}
+
+// defaultLogFormatter is the formatter of LogFormatDefault.
+var defaultLogFormatter = func(param LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[FOX] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v %s %s\n",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		param.Path,
+		param.Route,
+		param.RequestID,
+	)
+}
+
+// commonLogFormatter is the formatter of LogFormatCommon.
+var commonLogFormatter = func(param LogFormatterParams) string {
+	return commonLogLine(param) + "\n"
+}
+
+// combinedLogFormatter is the formatter of LogFormatCombined.
+var combinedLogFormatter = func(param LogFormatterParams) string {
+	return fmt.Sprintf("%s %q %q\n", commonLogLine(param), param.Request.Referer(), param.Request.UserAgent())
+}
+
+func commonLogLine(param LogFormatterParams) string {
+	user := "-"
+	if u := param.Request.URL.User; u != nil && u.Username() != "" {
+		user = u.Username()
+	}
+
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %d",
+		logValue(param.ClientIP),
+		user,
+		param.TimeStamp.Format("02/Jan/2006:15:04:05 -0700"),
+		param.Method, param.Request.URL.RequestURI(), param.Request.Proto,
+		param.StatusCode,
+		param.BodySize,
+	)
+}
+
+// jsonLogFormatter is the formatter of LogFormatJSON.
+var jsonLogFormatter = func(param LogFormatterParams) string {
+	data, _ := json.Marshal(struct {
+		Time      string  `json:"time"`
+		Method    string  `json:"method"`
+		Path      string  `json:"path"`
+		Route     string  `json:"route,omitempty"`
+		Status    int     `json:"status"`
+		Size      int     `json:"size"`
+		Latency   float64 `json:"latency_ms"`
+		ClientIP  string  `json:"client_ip"`
+		RequestID string  `json:"request_id,omitempty"`
+		Proto     string  `json:"proto"`
+		UserAgent string  `json:"user_agent,omitempty"`
+		Referer   string  `json:"referer,omitempty"`
+	}{
+		Time:      param.TimeStamp.Format(time.RFC3339Nano),
+		Method:    param.Method,
+		Path:      param.Path,
+		Route:     param.Route,
+		Status:    param.StatusCode,
+		Size:      param.BodySize,
+		Latency:   float64(param.Latency) / float64(time.Millisecond),
+		ClientIP:  param.ClientIP,
+		RequestID: param.RequestID,
+		Proto:     param.Request.Proto,
+		UserAgent: param.Request.UserAgent(),
+		Referer:   param.Request.Referer(),
+	})
+	return string(data) + "\n"
+}
+
+// templateLogFormatter returns the formatter executing the text/template format.
+func templateLogFormatter(format string) LogFormatter {
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+	tmpl := template.Must(template.New("logger").Parse(format))
+
+	return func(param LogFormatterParams) string {
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, &param); err != nil {
+			return fmt.Sprintf("[FOX] logger template error: %v\n", err)
+		}
+		return buf.String()
+	}
+}
+
+// logFormatter returns the formatter of the format.
+func logFormatter(format string) LogFormatter {
+	switch format {
+	case "", LogFormatDefault:
+		return defaultLogFormatter
+	case LogFormatCommon:
+		return commonLogFormatter
+	case LogFormatCombined:
+		return combinedLogFormatter
+	case LogFormatJSON:
+		return jsonLogFormatter
+	}
+	return templateLogFormatter(format)
+}
+
+// logValue returns "-" for the empty values of the Common Log Format.
+func logValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// AccessLog instances a middleware that will write the access logs to fox.DefaultWriter.
+// By default, fox.DefaultWriter = os.Stdout.
+//
+//	router.Use(fox.AccessLog())
+func AccessLog() HandlerFunc {
+	return AccessLogWithConfig(AccessLogConfig{})
+}
+
+// AccessLogWithFormat instance an AccessLog middleware with the specified log format,
+// one of the LogFormat constants or a text/template.
+func AccessLogWithFormat(format string) HandlerFunc {
+	return AccessLogWithConfig(AccessLogConfig{Format: format})
+}
+
+// AccessLogWithWriter instance an AccessLog middleware with the specified writer buffer.
+// Example: os.Stdout, a file opened in write mode, a socket...
+func AccessLogWithWriter(out io.Writer, notlogged ...string) HandlerFunc {
+	return AccessLogWithConfig(AccessLogConfig{
+		Output:    out,
+		SkipPaths: notlogged,
+	})
+}
+
+// AccessLogWithConfig instance an AccessLog middleware with config, it panics if Format is
+// an invalid template.
+func AccessLogWithConfig(conf AccessLogConfig) HandlerFunc {
+	formatter := conf.Formatter
+	if formatter == nil {
+		formatter = logFormatter(conf.Format)
+	}
+
+	out := conf.Output
+	if out == nil {
+		out = DefaultWriter
+	}
+	isTerm := isTerminal(out)
+
+	var skip map[string]struct{}
+	if length := len(conf.SkipPaths); length > 0 {
+		skip = make(map[string]struct{}, length)
+		for _, path := range conf.SkipPaths {
+			skip[path] = struct{}{}
+		}
+	}
+
+	return func(c *Context) {
+		// Start timer
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		// Process request
+		c.Next()
+
+		// Log only when path is not being skipped
+		if _, ok := skip[path]; ok || (conf.Skip != nil && conf.Skip(c)) {
+			return
+		}
+
+		param := LogFormatterParams{
+			Request:    c.Request,
+			isTerm:     isTerm,
+			Keys:       c.Keys,
+			TimeStamp:  time.Now(),
+			ClientIP:   c.ClientIP(),
+			Method:     c.Request.Method,
+			StatusCode: c.Writer.Status(),
+			BodySize:   c.Writer.Size(),
+			Path:       path,
+			Route:      c.FullPath(),
+			RequestID:  c.RequestID(),
+		}
+		param.Latency = param.TimeStamp.Sub(start)
+		if param.BodySize < 0 {
+			param.BodySize = 0
+		}
+
+		fmt.Fprint(out, formatter(param))
+	}
+}
+
+// isTerminal reports whether w is a terminal, which displays the colors.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,182 @@
+package fox
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLog(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	router := New()
+	router.Use(RequestID(), AccessLogWithWriter(buffer))
+	router.GET("/users/:id", func() string { return "fox" })
+	router.POST("/users", func() (any, int) { return "created", http.StatusCreated })
+
+	PerformRequest(router, http.MethodGet, "/users/1?q=x", nil)
+	assert.Contains(t, buffer.String(), "200")
+	assert.Contains(t, buffer.String(), "GET")
+	assert.Contains(t, buffer.String(), `"/users/1"`)
+	assert.Contains(t, buffer.String(), "/users/:id")
+	assert.Contains(t, buffer.String(), "192.0.2.1")
+	assert.NotContains(t, buffer.String(), reset)
+
+	buffer.Reset()
+	PerformRequest(router, http.MethodPost, "/users", http.Header{"X-Request-Id": []string{"req-1"}})
+	assert.Contains(t, buffer.String(), "201")
+	assert.Contains(t, buffer.String(), "POST")
+	assert.Contains(t, buffer.String(), "req-1")
+}
+
+func TestAccessLogCommonFormats(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	router := New()
+	router.Use(AccessLogWithConfig(AccessLogConfig{Format: LogFormatCommon, Output: buffer}))
+	router.GET("/users/:id", func() string { return "fox" })
+
+	PerformRequest(router, http.MethodGet, "/users/1?q=x", nil)
+	assert.Regexp(t, `^192\.0\.2\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /users/1\?q=x HTTP/1\.1" 200 3\n$`, buffer.String())
+
+	buffer.Reset()
+	router = New()
+	router.Use(AccessLogWithConfig(AccessLogConfig{Format: LogFormatCombined, Output: buffer}))
+	router.GET("/", func() {})
+
+	PerformRequest(router, http.MethodGet, "/", http.Header{"Referer": []string{"https://example.com/"}, "User-Agent": []string{"curl/8.0"}})
+	assert.Regexp(t, `"GET / HTTP/1\.1" 200 0 "https://example\.com/" "curl/8\.0"\n$`, buffer.String())
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	router := New()
+	router.Use(RequestID(), AccessLogWithConfig(AccessLogConfig{Format: LogFormatJSON, Output: buffer}))
+	router.GET("/users/:id", func() (any, int) { return "not found", http.StatusNotFound })
+
+	PerformRequest(router, http.MethodGet, "/users/1", http.Header{"X-Request-Id": []string{"req-1"}})
+	PerformRequest(router, http.MethodGet, "/users/2", nil)
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	if !assert.Len(t, lines, 2) {
+		return
+	}
+
+	var entry map[string]any
+	assert.NoError(t, stdjson.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/users/1", entry["path"])
+	assert.Equal(t, "/users/:id", entry["route"])
+	assert.Equal(t, float64(404), entry["status"])
+	assert.Equal(t, float64(9), entry["size"])
+	assert.Equal(t, "192.0.2.1", entry["client_ip"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Contains(t, entry, "latency_ms")
+	_, err := time.Parse(time.RFC3339Nano, entry["time"].(string))
+	assert.NoError(t, err)
+}
+
+func TestAccessLogTemplate(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	router := New()
+	router.Use(AccessLogWithConfig(AccessLogConfig{Format: "{{.Method}} {{.Route}} {{.StatusCode}} {{.BodySize}}", Output: buffer}))
+	router.GET("/users/:id", func() string { return "fox" })
+
+	PerformRequest(router, http.MethodGet, "/users/1", nil)
+	assert.Equal(t, "GET /users/:id 200 3\n", buffer.String())
+
+	assert.Panics(t, func() {
+		AccessLogWithFormat("{{.Method")
+	})
+
+	buffer.Reset()
+	router = New()
+	router.Use(AccessLogWithConfig(AccessLogConfig{
+		Output: buffer,
+		Formatter: func(params LogFormatterParams) string {
+			return params.Method + " " + params.Path + "\n"
+		},
+	}))
+	router.GET("/", func() {})
+	PerformRequest(router, http.MethodGet, "/", nil)
+	assert.Equal(t, "GET /\n", buffer.String())
+}
+
+func TestAccessLogSkip(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	router := New()
+	router.Use(AccessLogWithConfig(AccessLogConfig{
+		Output:    buffer,
+		SkipPaths: []string{"/healthz"},
+		Skip: func(c *Context) bool {
+			return c.Writer.Status() == http.StatusNoContent
+		},
+	}))
+	router.GET("/healthz", func() {})
+	router.GET("/empty", func(c *Context) { c.Writer.WriteHeader(http.StatusNoContent) })
+	router.GET("/logged", func() {})
+
+	PerformRequest(router, http.MethodGet, "/healthz", nil)
+	PerformRequest(router, http.MethodGet, "/empty", nil)
+	assert.Empty(t, buffer.String())
+
+	PerformRequest(router, http.MethodGet, "/logged", nil)
+	assert.Contains(t, buffer.String(), "/logged")
+}
+
+func TestAccessLogColor(t *testing.T) {
+	params := LogFormatterParams{StatusCode: http.StatusOK, Method: http.MethodGet, isTerm: true}
+	assert.Equal(t, green, params.StatusCodeColor())
+	assert.Equal(t, blue, params.MethodColor())
+
+	captureOutput(t, DebugMode, func() {
+		assert.True(t, params.IsOutputColor())
+		assert.Contains(t, defaultLogFormatter(params), green+" 200 "+reset)
+	})
+	captureOutput(t, ReleaseMode, func() {
+		assert.False(t, params.IsOutputColor())
+		assert.NotContains(t, defaultLogFormatter(params), reset)
+	})
+
+	for code, color := range map[int]string{100: white, 301: white, 404: yellow, 500: red} {
+		params.StatusCode = code
+		assert.Equal(t, color, params.StatusCodeColor())
+	}
+	assert.False(t, isTerminal(new(bytes.Buffer)))
+}
+
+func TestContextClientIP(t *testing.T) {
+	router := New()
+	router.GET("/", func(c *Context) string { return c.ClientIP() })
+
+	perform := func(remoteAddr string, header http.Header) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	forwarded := http.Header{"X-Forwarded-For": []string{"203.0.113.9, 10.0.0.1"}}
+	assert.Equal(t, "198.51.100.1", perform("198.51.100.1:1234", forwarded))
+	assert.Equal(t, "10.0.0.1", perform("127.0.0.1:1234", forwarded))
+	assert.Equal(t, "203.0.113.5", perform("[::1]:1234", http.Header{"X-Real-Ip": []string{"203.0.113.5"}}))
+	assert.Equal(t, "", perform("@", nil))
+
+	assert.NoError(t, router.SetTrustedProxies([]string{"127.0.0.1", "10.0.0.0/8"}))
+	assert.Equal(t, "203.0.113.9", perform("127.0.0.1:1234", forwarded))
+	assert.Equal(t, "::1", perform("[::1]:1234", forwarded))
+
+	assert.NoError(t, router.SetTrustedProxies(nil))
+	assert.Equal(t, "127.0.0.1", perform("127.0.0.1:1234", forwarded))
+
+	assert.Error(t, router.SetTrustedProxies([]string{"example.com"}))
+	assert.Error(t, router.SetTrustedProxies([]string{"10.0.0.0/33"}))
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"strconv"
+
+	"github.com/miclle/fox"
+)
+
+// APIKeyConfig defines the config for the APIKey middleware.
+type APIKeyConfig struct {
+	// Lookup returns the API key of the request, FromHeader("X-API-Key") by default.
+	Lookup Extractor
+
+	// Validate returns the user of the key, ok is false if the key isn't valid.
+	// Compare the keys with crypto/subtle.ConstantTimeCompare, or their hashes.
+	Validate func(c *fox.Context, key string) (user string, ok bool)
+
+	// Realm is the protection space sent in the challenge, "API" by default.
+	Realm string
+}
+
+// APIKey returns a middleware authenticating the requests by an API key, the key is
+// stored under APIKeyKey and its user under UserKey. The requests without a valid key are
+// answered with 401 Unauthorized.
+//
+//	router.Use(auth.APIKey(auth.APIKeyConfig{
+//		Lookup:   auth.FromQuery("api_key"),
+//		Validate: func(c *fox.Context, key string) (string, bool) { return store.User(key) },
+//	}))
+func APIKey(config APIKeyConfig) fox.HandlerFunc {
+	if config.Validate == nil {
+		panic("auth: APIKeyConfig.Validate must not be nil")
+	}
+	lookup := config.Lookup
+	if lookup == nil {
+		lookup = FromHeader("X-API-Key")
+	}
+	realm := config.Realm
+	if realm == "" {
+		realm = "API"
+	}
+	challenge := "APIKey realm=" + strconv.Quote(realm)
+
+	return func(c *fox.Context) {
+		key := lookup(c)
+		if key == "" {
+			unauthorized(c, challenge)
+			return
+		}
+
+		user, ok := config.Validate(c, key)
+		if !ok {
+			unauthorized(c, challenge)
+			return
+		}
+		withValues(c, APIKeyKey, key, UserKey, user)
+	}
+}
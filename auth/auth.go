@@ -0,0 +1,108 @@
+// Package auth provides the authentication middlewares of fox: BasicAuth, APIKey and the
+// bearer JWT. The authenticated user and the verified claims are stored in the request
+// context under the typed keys of the package, read them with User and ClaimsFrom:
+//
+//	api := router.Group("/api", auth.JWT(auth.JWTConfig{Key: secret, Issuer: "https://example.com"}))
+//	api.GET("/me", func(c *fox.Context) string {
+//		return auth.User(c)
+//	})
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/miclle/fox"
+)
+
+// contextKey is the type of the keys the middlewares store in the request context.
+type contextKey string
+
+const (
+	// UserKey is the key of the authenticated user name, a string: the user name of
+	// BasicAuth, the user of the API key, or the subject of the JWT.
+	UserKey contextKey = "auth_user"
+
+	// APIKeyKey is the key of the API key verified by APIKey, a string.
+	APIKeyKey contextKey = "auth_api_key"
+
+	// ClaimsKey is the key of the Claims of the JWT verified by JWT.
+	ClaimsKey contextKey = "auth_claims"
+)
+
+// User returns the authenticated user stored in ctx by the middlewares, ctx may be
+// the *fox.Context.
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(UserKey).(string)
+	return user
+}
+
+// ClaimsFrom returns the claims of the JWT verified by the JWT middleware.
+func ClaimsFrom(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(ClaimsKey).(Claims)
+	return claims, ok
+}
+
+// Extractor returns the credential of the request, e.g. the API key or the token,
+// "" if it's missing.
+type Extractor func(c *fox.Context) string
+
+// FromHeader returns an Extractor reading the header.
+func FromHeader(name string) Extractor {
+	return func(c *fox.Context) string {
+		return c.Request.Header.Get(name)
+	}
+}
+
+// FromQuery returns an Extractor reading the query parameter.
+func FromQuery(name string) Extractor {
+	return func(c *fox.Context) string {
+		return c.Request.URL.Query().Get(name)
+	}
+}
+
+// FromCookie returns an Extractor reading the cookie.
+func FromCookie(name string) Extractor {
+	return func(c *fox.Context) string {
+		cookie, err := c.Request.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		value, err := url.QueryUnescape(cookie.Value)
+		if err != nil {
+			return ""
+		}
+		return value
+	}
+}
+
+// FromBearer returns the token of the "Authorization: Bearer <token>" header.
+func FromBearer(c *fox.Context) string {
+	const prefix = "Bearer "
+	header := c.Request.Header.Get("Authorization")
+	if len(header) > len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+		return strings.TrimSpace(header[len(prefix):])
+	}
+	return ""
+}
+
+// withValues stores the key/value pairs in the request context.
+func withValues(c *fox.Context, keysAndValues ...any) {
+	ctx := c.Request.Context()
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		ctx = context.WithValue(ctx, keysAndValues[i], keysAndValues[i+1])
+	}
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// unauthorized aborts the request with 401 Unauthorized and the challenge.
+func unauthorized(c *fox.Context, challenge string) {
+	if challenge != "" {
+		c.Writer.Header().Set("WWW-Authenticate", challenge)
+	}
+	c.Writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	c.AbortWithStatus(http.StatusUnauthorized)
+	c.Writer.WriteString("401 unauthorized")
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/miclle/fox"
+)
+
+func performRequest(r http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func newRouter(middleware fox.HandlerFunc) *fox.Engine {
+	router := fox.New()
+	router.GET("/", middleware, func(c *fox.Context) string {
+		return User(c)
+	})
+	return router
+}
+
+func TestBasicAuth(t *testing.T) {
+	router := newRouter(BasicAuthWithConfig(BasicAuthConfig{
+		Realm:    "admin",
+		Accounts: Accounts{"admin": "secret"},
+		Validator: func(c *fox.Context, user, password string) bool {
+			return user == "guest" && password == "guest"
+		},
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("admin", "secret")
+	w := performRequest(router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "admin", w.Body.String())
+
+	req.SetBasicAuth("guest", "guest")
+	w = performRequest(router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "guest", w.Body.String())
+
+	for _, credentials := range [][2]string{{"admin", "wrong"}, {"admin", ""}, {"guest", "wrong"}, {"", "secret"}} {
+		req.SetBasicAuth(credentials[0], credentials[1])
+		w = performRequest(router, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, `Basic realm="admin", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, "401 unauthorized", w.Body.String())
+	}
+
+	w = performRequest(router, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	router = newRouter(BasicAuth(Accounts{"admin": "secret"}, ""))
+	w = performRequest(router, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, `Basic realm="Authorization Required", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestAPIKey(t *testing.T) {
+	validate := func(c *fox.Context, key string) (string, bool) {
+		if key == "key-1" {
+			return "alice", true
+		}
+		return "", false
+	}
+
+	router := fox.New()
+	router.GET("/header", APIKey(APIKeyConfig{Validate: validate}), func(c *fox.Context) string {
+		key, _ := c.Value(APIKeyKey).(string)
+		return User(c) + " " + key
+	})
+	router.GET("/query", APIKey(APIKeyConfig{Validate: validate, Lookup: FromQuery("api_key")}), func(c *fox.Context) string {
+		return User(c)
+	})
+	router.GET("/cookie", APIKey(APIKeyConfig{Validate: validate, Lookup: FromCookie("api_key")}), func(c *fox.Context) string {
+		return User(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/header", nil)
+	req.Header.Set("X-API-Key", "key-1")
+	w := performRequest(router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice key-1", w.Body.String())
+
+	req.Header.Set("X-API-Key", "key-2")
+	w = performRequest(router, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `APIKey realm="API"`, w.Header().Get("WWW-Authenticate"))
+
+	w = performRequest(router, httptest.NewRequest(http.MethodGet, "/header", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = performRequest(router, httptest.NewRequest(http.MethodGet, "/query?api_key=key-1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice", w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/cookie", nil)
+	req.AddCookie(&http.Cookie{Name: "api_key", Value: "key-1"})
+	w = performRequest(router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice", w.Body.String())
+
+	assert.Panics(t, func() { APIKey(APIKeyConfig{}) })
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"strconv"
+
+	"github.com/miclle/fox"
+)
+
+// Accounts maps the user names to the passwords of BasicAuth.
+type Accounts map[string]string
+
+// BasicAuthConfig defines the config for the BasicAuth middleware.
+type BasicAuthConfig struct {
+	// Realm is the protection space sent in the challenge, "Authorization Required" by default.
+	Realm string
+
+	// Accounts are the users allowed.
+	Accounts Accounts
+
+	// Optional. Validator checks the users not in Accounts, e.g. against a database.
+	Validator func(c *fox.Context, user, password string) bool
+}
+
+// BasicAuth returns a HTTP Basic Authentication middleware, the user name is stored under
+// UserKey. The unauthenticated requests are answered with 401 Unauthorized and the
+// challenge of realm.
+//
+//	router.Use(auth.BasicAuth(auth.Accounts{"admin": "secret"}, ""))
+func BasicAuth(accounts Accounts, realm string) fox.HandlerFunc {
+	return BasicAuthWithConfig(BasicAuthConfig{Realm: realm, Accounts: accounts})
+}
+
+// BasicAuthWithConfig returns a BasicAuth middleware with config.
+func BasicAuthWithConfig(config BasicAuthConfig) fox.HandlerFunc {
+	realm := config.Realm
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	challenge := "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`
+
+	// compare the digests, so the time doesn't depend on the length of the passwords
+	digests := make(map[string][sha256.Size]byte, len(config.Accounts))
+	for user, password := range config.Accounts {
+		digests[user] = sha256.Sum256([]byte(password))
+	}
+
+	return func(c *fox.Context) {
+		user, password, ok := c.Request.BasicAuth()
+		if ok {
+			digest, found := digests[user]
+			given := sha256.Sum256([]byte(password))
+			ok = subtle.ConstantTimeCompare(digest[:], given[:]) == 1 && found
+			if !ok && !found && config.Validator != nil {
+				ok = config.Validator(c, user, password)
+			}
+		}
+
+		if !ok {
+			unauthorized(c, challenge)
+			return
+		}
+		withValues(c, UserKey, user)
+	}
+}
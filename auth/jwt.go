@@ -0,0 +1,358 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // register SHA-256 for crypto.Hash
+	_ "crypto/sha512" // register SHA-384 and SHA-512 for crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miclle/fox"
+)
+
+// The errors of a JWT failing the verification.
+var (
+	ErrTokenMalformed   = errors.New("auth: token is malformed")
+	ErrTokenAlgorithm   = errors.New("auth: token algorithm is not allowed")
+	ErrTokenSignature   = errors.New("auth: token signature is invalid")
+	ErrTokenExpired     = errors.New("auth: token is expired")
+	ErrTokenNotValidYet = errors.New("auth: token is not valid yet")
+	ErrTokenIssuedAt    = errors.New("auth: token is issued in the future")
+	ErrTokenIssuer      = errors.New("auth: token issuer is invalid")
+	ErrTokenAudience    = errors.New("auth: token audience is invalid")
+	ErrKeyNotFound      = errors.New("auth: token key is not found")
+)
+
+// Claims are the claims of a JWT, the numbers are json.Number.
+type Claims map[string]any
+
+// Subject returns the "sub" claim.
+func (claims Claims) Subject() string {
+	return claims.String("sub")
+}
+
+// Issuer returns the "iss" claim.
+func (claims Claims) Issuer() string {
+	return claims.String("iss")
+}
+
+// Audience returns the "aud" claim, a string or an array of strings.
+func (claims Claims) Audience() []string {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []any:
+		audience := make([]string, 0, len(aud))
+		for _, v := range aud {
+			if s, ok := v.(string); ok {
+				audience = append(audience, s)
+			}
+		}
+		return audience
+	}
+	return nil
+}
+
+// ExpiresAt returns the "exp" claim, ok is false if it's missing.
+func (claims Claims) ExpiresAt() (t time.Time, ok bool) {
+	return claims.Time("exp")
+}
+
+// NotBefore returns the "nbf" claim, ok is false if it's missing.
+func (claims Claims) NotBefore() (t time.Time, ok bool) {
+	return claims.Time("nbf")
+}
+
+// IssuedAt returns the "iat" claim, ok is false if it's missing.
+func (claims Claims) IssuedAt() (t time.Time, ok bool) {
+	return claims.Time("iat")
+}
+
+// String returns the string claim of name.
+func (claims Claims) String(name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+// Time returns the NumericDate claim of name, seconds since the epoch.
+func (claims Claims) Time(name string) (t time.Time, ok bool) {
+	n, ok := claims[name].(json.Number)
+	if !ok {
+		return t, false
+	}
+	seconds, err := n.Float64()
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return t, false
+	}
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*1e9)), true
+}
+
+// JWTConfig defines the config for the JWT middleware.
+type JWTConfig struct {
+	// Key verifies the tokens: a []byte secret for HS256, HS384 and HS512, a *rsa.PublicKey
+	// for RS256 or a *ecdsa.PublicKey of the P-256 curve for ES256.
+	Key any
+
+	// KeySet verifies the tokens by their "kid" header, the tokens without "kid" are
+	// verified by Key, or by the key of KeySet if it has only one.
+	KeySet *JWKS
+
+	// Algorithms are the allowed algorithms, all the algorithms supported by the key by default.
+	Algorithms []string
+
+	// Issuer is the required "iss" claim if it's not empty.
+	Issuer string
+
+	// Audience is required to be in the "aud" claim if it's not empty.
+	Audience string
+
+	// ClockSkew is the leeway of the "exp", "nbf" and "iat" checks.
+	ClockSkew time.Duration
+
+	// Lookup returns the token of the request, FromBearer by default.
+	Lookup Extractor
+
+	// Realm is the protection space sent in the challenge.
+	Realm string
+}
+
+// JWT returns a middleware verifying the bearer JWT of the requests, the claims are stored
+// under ClaimsKey and the subject under UserKey. The requests without a valid token are
+// answered with 401 Unauthorized and a Bearer challenge of RFC 6750.
+//
+//	keys, err := auth.LoadJWKS("/etc/fox/jwks.json")
+//	...
+//	router.Use(auth.JWT(auth.JWTConfig{
+//		KeySet:    keys,
+//		Issuer:    "https://accounts.example.com",
+//		Audience:  "api",
+//		ClockSkew: time.Minute,
+//	}))
+func JWT(config JWTConfig) fox.HandlerFunc {
+	if config.Key == nil && config.KeySet == nil {
+		panic("auth: JWTConfig.Key or JWTConfig.KeySet is required")
+	}
+	lookup := config.Lookup
+	if lookup == nil {
+		lookup = FromBearer
+	}
+	realm := ""
+	if config.Realm != "" {
+		realm = "realm=" + strconv.Quote(config.Realm)
+	}
+
+	return func(c *fox.Context) {
+		token := lookup(c)
+		if token == "" {
+			unauthorized(c, strings.TrimSpace("Bearer "+realm))
+			return
+		}
+
+		claims, err := ParseJWT(token, config)
+		if err != nil {
+			params := []string{`error="invalid_token"`, "error_description=" + strconv.Quote(err.Error())}
+			if realm != "" {
+				params = append([]string{realm}, params...)
+			}
+			unauthorized(c, "Bearer "+strings.Join(params, ", "))
+			return
+		}
+		withValues(c, ClaimsKey, claims, UserKey, claims.Subject())
+	}
+}
+
+// ParseJWT verifies the signature and the claims of the compact serialized token.
+func ParseJWT(token string, config JWTConfig) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	if len(config.Algorithms) > 0 && !contains(config.Algorithms, header.Alg) {
+		return nil, ErrTokenAlgorithm
+	}
+	key, err := config.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims == nil {
+		return nil, ErrTokenMalformed
+	}
+	if err = config.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// key returns the key of kid.
+func (config JWTConfig) key(kid string) (any, error) {
+	if config.KeySet != nil {
+		if key, ok := config.KeySet.Key(kid); ok {
+			return key, nil
+		}
+		if kid == "" && config.Key == nil && len(config.KeySet.keys) == 1 {
+			for _, key := range config.KeySet.keys {
+				return key, nil
+			}
+		}
+		if kid != "" || config.Key == nil {
+			return nil, ErrKeyNotFound
+		}
+	}
+	return config.Key, nil
+}
+
+// validate checks the registered claims.
+func (config JWTConfig) validate(claims Claims) error {
+	now := time.Now()
+
+	if _, ok := claims["exp"]; ok {
+		exp, ok := claims.ExpiresAt()
+		if !ok {
+			return ErrTokenMalformed
+		}
+		if !now.Before(exp.Add(config.ClockSkew)) {
+			return ErrTokenExpired
+		}
+	}
+	if _, ok := claims["nbf"]; ok {
+		nbf, ok := claims.NotBefore()
+		if !ok {
+			return ErrTokenMalformed
+		}
+		if now.Add(config.ClockSkew).Before(nbf) {
+			return ErrTokenNotValidYet
+		}
+	}
+	if _, ok := claims["iat"]; ok {
+		iat, ok := claims.IssuedAt()
+		if !ok {
+			return ErrTokenMalformed
+		}
+		if now.Add(config.ClockSkew).Before(iat) {
+			return ErrTokenIssuedAt
+		}
+	}
+
+	if config.Issuer != "" && claims.Issuer() != config.Issuer {
+		return ErrTokenIssuer
+	}
+	if config.Audience != "" && !contains(claims.Audience(), config.Audience) {
+		return ErrTokenAudience
+	}
+	return nil
+}
+
+// decodeSegment decodes the base64url encoded JSON segment into v.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrTokenMalformed
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(v); err != nil {
+		return ErrTokenMalformed
+	}
+	return nil
+}
+
+// verifySignature verifies the signature of the signing input by alg, the type of key
+// must match alg, so a public key can't be used as a HMAC secret.
+func verifySignature(alg string, key any, input string, signature []byte) error {
+	switch alg {
+	case "HS256", "HS384", "HS512":
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return ErrTokenAlgorithm
+		}
+		mac := hmac.New(hashOf(alg).New, secret)
+		mac.Write([]byte(input))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrTokenSignature
+		}
+		return nil
+
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrTokenAlgorithm
+		}
+		digest := crypto.SHA256.New()
+		digest.Write([]byte(input))
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest.Sum(nil), signature) != nil {
+			return ErrTokenSignature
+		}
+		return nil
+
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() {
+			return ErrTokenAlgorithm
+		}
+		if len(signature) != 64 {
+			return ErrTokenSignature
+		}
+		digest := crypto.SHA256.New()
+		digest.Write([]byte(input))
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest.Sum(nil), r, s) {
+			return ErrTokenSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrTokenAlgorithm, alg)
+}
+
+func hashOf(alg string) crypto.Hash {
+	switch alg {
+	case "HS384":
+		return crypto.SHA384
+	case "HS512":
+		return crypto.SHA512
+	}
+	return crypto.SHA256
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/miclle/fox"
+)
+
+// signJWT returns the token of the claims signed by key with alg.
+func signJWT(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	header := map[string]any{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		assert.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	input := encode(header) + "." + encode(claims)
+
+	var signature []byte
+	switch alg {
+	case "HS256", "HS384", "HS512":
+		mac := hmac.New(hashOf(alg).New, key.([]byte))
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case "RS256":
+		digest := crypto.SHA256.New()
+		digest.Write([]byte(input))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest.Sum(nil))
+		assert.NoError(t, err)
+		signature = sig
+	case "ES256":
+		digest := crypto.SHA256.New()
+		digest.Write([]byte(input))
+		r, s, err := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest.Sum(nil))
+		assert.NoError(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestParseJWT(t *testing.T) {
+	secret := []byte("secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	now := time.Now().Unix()
+	claims := map[string]any{"sub": "alice", "iss": "fox", "aud": []string{"api", "web"}, "exp": now + 60, "iat": now}
+
+	for _, alg := range []string{"HS256", "HS384", "HS512"} {
+		parsed, err := ParseJWT(signJWT(t, alg, "", secret, claims), JWTConfig{Key: secret})
+		assert.NoError(t, err, alg)
+		assert.Equal(t, "alice", parsed.Subject())
+		assert.Equal(t, "fox", parsed.Issuer())
+		assert.Equal(t, []string{"api", "web"}, parsed.Audience())
+		exp, ok := parsed.ExpiresAt()
+		assert.True(t, ok)
+		assert.Equal(t, now+60, exp.Unix())
+	}
+
+	_, err = ParseJWT(signJWT(t, "RS256", "", rsaKey, claims), JWTConfig{Key: &rsaKey.PublicKey})
+	assert.NoError(t, err)
+	_, err = ParseJWT(signJWT(t, "ES256", "", ecKey, claims), JWTConfig{Key: &ecKey.PublicKey})
+	assert.NoError(t, err)
+
+	// signature
+	_, err = ParseJWT(signJWT(t, "HS256", "", []byte("other"), claims), JWTConfig{Key: secret})
+	assert.ErrorIs(t, err, ErrTokenSignature)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, err = ParseJWT(signJWT(t, "ES256", "", otherKey, claims), JWTConfig{Key: &ecKey.PublicKey})
+	assert.ErrorIs(t, err, ErrTokenSignature)
+
+	// algorithm
+	_, err = ParseJWT(signJWT(t, "HS256", "", secret, claims), JWTConfig{Key: &rsaKey.PublicKey})
+	assert.ErrorIs(t, err, ErrTokenAlgorithm)
+	_, err = ParseJWT(signJWT(t, "HS256", "", secret, claims), JWTConfig{Key: secret, Algorithms: []string{"HS512"}})
+	assert.ErrorIs(t, err, ErrTokenAlgorithm)
+	_, err = ParseJWT(signJWT(t, "none", "", nil, claims), JWTConfig{Key: secret})
+	assert.ErrorIs(t, err, ErrTokenAlgorithm)
+
+	// malformed
+	for _, token := range []string{"", "a.b", "a.b.c", "e30.e30.!", signJWT(t, "HS256", "", secret, nil)} {
+		_, err = ParseJWT(token, JWTConfig{Key: secret})
+		assert.ErrorIs(t, err, ErrTokenMalformed, token)
+	}
+
+	// registered claims
+	tests := []struct {
+		claims map[string]any
+		config JWTConfig
+		err    error
+	}{
+		{map[string]any{"exp": now - 1}, JWTConfig{}, ErrTokenExpired},
+		{map[string]any{"exp": now - 1}, JWTConfig{ClockSkew: time.Minute}, nil},
+		{map[string]any{"exp": "tomorrow"}, JWTConfig{}, ErrTokenMalformed},
+		{map[string]any{"nbf": now + 60}, JWTConfig{}, ErrTokenNotValidYet},
+		{map[string]any{"nbf": now + 30}, JWTConfig{ClockSkew: time.Minute}, nil},
+		{map[string]any{"iat": now + 60}, JWTConfig{}, ErrTokenIssuedAt},
+		{map[string]any{"iat": now + 30}, JWTConfig{ClockSkew: time.Minute}, nil},
+		{map[string]any{"iss": "other"}, JWTConfig{Issuer: "fox"}, ErrTokenIssuer},
+		{map[string]any{"iss": "fox"}, JWTConfig{Issuer: "fox"}, nil},
+		{map[string]any{"aud": "web"}, JWTConfig{Audience: "api"}, ErrTokenAudience},
+		{map[string]any{"aud": "api"}, JWTConfig{Audience: "api"}, nil},
+		{map[string]any{"aud": []string{"web", "api"}}, JWTConfig{Audience: "api"}, nil},
+	}
+	for _, tt := range tests {
+		tt.config.Key = secret
+		_, err = ParseJWT(signJWT(t, "HS256", "", secret, tt.claims), tt.config)
+		if tt.err == nil {
+			assert.NoError(t, err, tt.claims)
+		} else {
+			assert.ErrorIs(t, err, tt.err, tt.claims)
+		}
+	}
+}
+
+func TestLoadJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "alg": "RS256", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": %q, "y": %q},
+		{"kty": "oct", "kid": "hmac", "k": %q},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "", "e": ""}
+	]}`, b64(rsaKey.N.Bytes()), b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		b64(ecKey.X.Bytes()), b64(ecKey.Y.Bytes()), b64([]byte("secret")))
+
+	filename := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(jwks), 0600))
+
+	keys, err := LoadJWKS(filename)
+	assert.NoError(t, err)
+	_, ok := keys.Key("enc")
+	assert.False(t, ok)
+
+	config := JWTConfig{KeySet: keys}
+	claims := map[string]any{"sub": "alice"}
+	_, err = ParseJWT(signJWT(t, "RS256", "rsa", rsaKey, claims), config)
+	assert.NoError(t, err)
+	_, err = ParseJWT(signJWT(t, "ES256", "ec", ecKey, claims), config)
+	assert.NoError(t, err)
+	_, err = ParseJWT(signJWT(t, "HS256", "hmac", []byte("secret"), claims), config)
+	assert.NoError(t, err)
+
+	// the key of kid must match the algorithm
+	_, err = ParseJWT(signJWT(t, "HS256", "rsa", []byte("secret"), claims), config)
+	assert.ErrorIs(t, err, ErrTokenAlgorithm)
+	_, err = ParseJWT(signJWT(t, "RS256", "unknown", rsaKey, claims), config)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = ParseJWT(signJWT(t, "RS256", "", rsaKey, claims), config)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, err = ParseJWKS([]byte(`{"keys": [{"kty": "EC", "crv": "P-384", "x": "AQ", "y": "AQ"}]}`))
+	assert.Error(t, err)
+	_, err = LoadJWKS(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestJWT(t *testing.T) {
+	secret := []byte("secret")
+
+	router := fox.New()
+	router.GET("/", JWT(JWTConfig{Key: secret, Realm: "api", Audience: "api"}), func(c *fox.Context) string {
+		claims, _ := ClaimsFrom(c)
+		return User(c) + " " + claims.String("role")
+	})
+
+	token := signJWT(t, "HS256", "", secret, map[string]any{"sub": "alice", "aud": "api", "role": "admin"})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := performRequest(router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice admin", w.Body.String())
+
+	req.Header.Set("Authorization", "bearer "+token)
+	w = performRequest(router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	token = signJWT(t, "HS256", "", secret, map[string]any{"sub": "alice", "aud": "web"})
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = performRequest(router, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="api", error="invalid_token", error_description="auth: token audience is invalid"`,
+		w.Header().Get("WWW-Authenticate"))
+
+	w = performRequest(router, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="api"`, w.Header().Get("WWW-Authenticate"))
+
+	assert.Panics(t, func() { JWT(JWTConfig{}) })
+}
@@ -0,0 +1,41 @@
+package fox
+
+import (
+	"net/http"
+	"strings"
+)
+
+// errBodyTooLarge is the error message of http.MaxBytesReader when the limit is exceeded.
+const errBodyTooLarge = "http: request body too large"
+
+// BodyLimit returns a middleware that limits the request body to n bytes, it overrides
+// Engine.MaxBodyBytes for the group or route it is attached to. 0 means unlimited.
+// Reading beyond the limit while binding or in the handler responds 413 Payload Too Large.
+//
+//	router.POST("/upload", fox.BodyLimit(100<<20), upload)
+func BodyLimit(n int64) HandlerFunc {
+	return func(c *Context) {
+		c.limitBody(n)
+	}
+}
+
+// limitBody replaces the request body with the original body limited to n bytes.
+func (c *Context) limitBody(n int64) {
+	if c.body == nil || c.body == http.NoBody || c.bodyBuffered {
+		return
+	}
+
+	if n <= 0 {
+		c.Request.Body = c.body
+		return
+	}
+
+	// use the underlying writer, so the server closes the connection once the limit is hit
+	c.Request.Body = http.MaxBytesReader(c.Writer.ResponseWriter, c.body, n)
+}
+
+// isBodyTooLarge reports whether err is caused by reading beyond the body limit.
+// It checks the message, http.MaxBytesError is only available since go1.19.
+func isBodyTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), errBodyTooLarge)
+}
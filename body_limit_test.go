@@ -0,0 +1,60 @@
+package fox
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	router := New()
+	router.MaxBodyBytes = 16
+
+	type CreateArgs struct {
+		Name string `json:"name"`
+	}
+	create := func(c *Context, args *CreateArgs) string {
+		return args.Name
+	}
+
+	type FormArgs struct {
+		Name string `pos:"form:name"`
+	}
+	form := func(c *Context, args *FormArgs) string {
+		return args.Name
+	}
+
+	read := func(c *Context) (string, error) {
+		data, err := io.ReadAll(c.Request.Body)
+		return string(data), err
+	}
+
+	router.POST("/json", create)
+	router.POST("/form", form)
+	router.POST("/read", read)
+	router.POST("/upload", BodyLimit(1024), create)
+
+	w := PerformRequest(router, http.MethodPost, "/json", nil, strings.NewReader(`{"name":"fox"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "fox", w.Body.String())
+
+	body := `{"name":"` + strings.Repeat("x", 32) + `"}`
+	w = PerformRequest(router, http.MethodPost, "/json", nil, strings.NewReader(body))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = PerformRequest(router, http.MethodPost, "/upload", nil, strings.NewReader(body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strings.Repeat("x", 32), w.Body.String())
+
+	header := http.Header{"Content-Type": []string{"application/x-www-form-urlencoded"}}
+	values := url.Values{"name": []string{strings.Repeat("x", 32)}}
+	w = PerformRequest(router, http.MethodPost, "/form", header, strings.NewReader(values.Encode()))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = PerformRequest(router, http.MethodPost, "/read", nil, strings.NewReader(body))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
@@ -36,12 +36,20 @@ func call(ctx *Context, handler HandlerFunc) (any, int, error) {
 	case 1:
 		values = funcValue.Call([]reflect.Value{ctxValue})
 	default:
+		// the multipart form is parsed with the engine's memory limit when a file or form
+		// field is bound, the JSON body is buffered by Context.Body only if it's bound
+		opts := easybind.Options{
+			DisallowUnknownFields: ctx.engine.StrictJSON,
+			UseNumber:             ctx.engine.UseJSONNumber,
+			MaxMultipartMemory:    ctx.engine.MaxMultipartMemory,
+		}
+
 		in := make([]reflect.Value, 0, numIn)
 		in = append(in, ctxValue)
 		for i := 1; i < numIn; i++ {
 			args := reflect.New(funcType.In(i)).Interface()
-			if err := easybind.Bind(ctx.Request, args, ctx.Params); err != nil {
-				// TODO(m) err maybe 413 Payload Too Large
+			if err := easybind.Bind(ctx.Request, args, ctx.Params, ctx, opts); err != nil {
+				// renderError turns it into 413 Payload Too Large if the body exceeds the limit
 				return nil, 400, err
 			}
 			in = append(in, reflect.ValueOf(args).Elem())
@@ -1,13 +1,25 @@
 package fox
 
 import (
+	"bytes"
+	"io"
+	"math"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/miclle/fox/render"
+	"github.com/miclle/fox/sessions"
 )
 
+// abortIndex is the index of an aborted handlers chain, it limits the number of handlers.
+const abortIndex = math.MaxInt8 >> 1
+
 // Context allows us to pass variables between middleware,
 // manage the flow, using logger with context
 type Context struct {
@@ -18,12 +30,40 @@ type Context struct {
 	engine   *Engine
 	handlers HandlersChain
 	index    int
+	fullPath string
+
+	// scope is the group served by the endpoint of the request, see RunListeners.
+	scope *RouterGroup
+
+	// body is the original request body, before any size limit is applied.
+	body io.ReadCloser
+
+	// bodyBytes is the request body buffered by Body.
+	bodyBytes    []byte
+	bodyBuffered bool
 
 	// This mutex protects Keys map.
 	mu sync.RWMutex
 
 	// Keys is a key/value pair exclusively for the context of each request.
 	Keys map[string]any
+
+	// logger is the child logger of Logger, created on the first call.
+	logger Logger
+
+	// templateFuncs are the template funcs of the request, see templateFuncs.
+	templateFuncs map[string]func() string
+
+	// csrfSecret is the secret of the CSRF token set by the CSRF middleware.
+	csrfSecret []byte
+
+	// cspNonce is the Content-Security-Policy nonce set by the Secure middleware.
+	cspNonce string
+
+	// session is the session of the Sessions middleware, loaded by Session.
+	session      *sessions.Session
+	sessionName  string
+	sessionStore sessions.Store
 }
 
 func (c *Context) reset(w http.ResponseWriter, req *http.Request) {
@@ -31,21 +71,41 @@ func (c *Context) reset(w http.ResponseWriter, req *http.Request) {
 		ResponseWriter: w,
 		size:           noWritten,
 		status:         defaultStatus,
+		logger:         c.engine.Logger,
 	}
 	c.Request = req
+	c.body = req.Body
+	c.bodyBytes = nil
+	c.bodyBuffered = false
 	*c.Params = (*c.Params)[:0]
 	c.handlers = nil
 	c.index = -1
+	c.fullPath = ""
+	c.scope = nil
+	c.logger = nil
+	c.templateFuncs = nil
+	c.csrfSecret = nil
+	c.cspNonce = ""
+	c.session = nil
+	c.sessionName = ""
+	c.sessionStore = nil
 	c.Keys = nil
 }
 
+// FullPath returns the matched route full path, e.g. "/users/:id", or "" for
+// requests that matched no route.
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 // Next should be used only inside middleware.
 func (c *Context) Next() {
 	c.index++
 	for c.index < len(c.handlers) {
 		res, code, err := call(c, c.handlers[c.index])
 		if err != nil {
-			c.renderError(err)
+			c.renderError(code, err)
+			c.Abort()
 			return
 		}
 		if res != nil || code != 0 {
@@ -55,10 +115,42 @@ func (c *Context) Next() {
 	}
 }
 
-// renderError ...
-func (c *Context) renderError(err error) {
-	c.Writer.WriteHeader(http.StatusInternalServerError)
-	c.Writer.Write([]byte(err.Error()))
+// IsAborted returns true if the current context was aborted.
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// Abort prevents pending handlers from being called. Note that this will not stop the current handler.
+// Let's say you have an authorization middleware that validates that the current request is authorized.
+// If the authorization fails (ex: the password does not match), call Abort to ensure the remaining handlers
+// for this request are not called.
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+// AbortWithStatus calls Abort and writes the headers with the specified status code.
+// For example, a failed attempt to authenticate a request could use: c.AbortWithStatus(401).
+func (c *Context) AbortWithStatus(code int) {
+	c.Writer.WriteHeader(code)
+	c.Writer.WriteHeaderNow()
+	c.Abort()
+}
+
+// renderError writes the error with the status code, 413 Payload Too Large if the
+// request body exceeds the limit, 500 Internal Server Error if code is not an error status.
+// The request ID set by the RequestID middleware follows the error message.
+func (c *Context) renderError(code int, err error) {
+	switch {
+	case isBodyTooLarge(err):
+		code = http.StatusRequestEntityTooLarge
+	case code < http.StatusBadRequest:
+		code = http.StatusInternalServerError
+	}
+	c.Writer.WriteHeader(code)
+	c.Writer.WriteString(err.Error())
+	if id := RequestIDFromContext(c.Request.Context()); id != "" {
+		c.Writer.WriteString(" (request_id=" + id + ")")
+	}
 }
 
 // render writes the response headers and calls render.render to render data.
@@ -71,15 +163,17 @@ func (c *Context) render(code int, res any) {
 	var r render.Render
 	switch v := res.(type) {
 	case error:
-		c.renderError(v)
+		c.renderError(code, v)
 		return
 	case string:
 		r = render.String{Format: v}
 	case render.Redirect:
 		r = v
 		c.Writer.WriteHeader(-1)
+	case render.HTML:
+		r = c.htmlRender(v)
 	case render.JSON, render.IndentedJSON, render.JsonpJSON, render.XML, render.Data,
-		render.HTML, render.YAML, render.Reader, render.ASCIIJSON, render.ProtoBuf:
+		render.YAML, render.Reader, render.ASCIIJSON, render.ProtoBuf:
 		r = v.(render.Render)
 	default:
 		r = render.JSON{Data: res}
@@ -87,7 +181,7 @@ func (c *Context) render(code int, res any) {
 
 	r.WriteContentType(c.Writer)
 	if err := r.Render(c.Writer); err != nil {
-		panic(err)
+		c.Logger().Error("render failed", "error", err)
 	}
 }
 
@@ -126,6 +220,167 @@ func (c *Context) MustGet(key string) any {
 	panic("Key \"" + key + "\" does not exist")
 }
 
+/************************************/
+/************ INPUT DATA ************/
+/************************************/
+
+// Body reads the request body once and returns it, the body is buffered in memory up to
+// the body limit, see BodyLimit and Engine.MaxBodyBytes. Without a limit the whole body is
+// buffered however large it is, so set one for the routes reading untrusted bodies.
+// c.Request.Body is reset to a fresh reader of the buffered body on every call, so binding,
+// signature checking and logging can all read it. The body is buffered only by Body, the
+// JSON binding of the handler arguments calls it, the other handlers can stream c.Request.Body.
+func (c *Context) Body() ([]byte, error) {
+	if !c.bodyBuffered {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.bodyBuffered = true
+			return nil, nil
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		c.bodyBytes, c.bodyBuffered = data, true
+	}
+
+	c.resetBody()
+	return c.bodyBytes, nil
+}
+
+// resetBody resets c.Request.Body to a fresh reader if the body is buffered.
+func (c *Context) resetBody() {
+	if c.bodyBuffered && c.bodyBytes != nil {
+		c.Request.Body = io.NopCloser(bytes.NewReader(c.bodyBytes))
+	}
+}
+
+// MultipartForm returns the parsed multipart form, including file uploads.
+// The form is parsed with engine.MaxMultipartMemory.
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	err := c.Request.ParseMultipartForm(c.engine.MaxMultipartMemory)
+	return c.Request.MultipartForm, err
+}
+
+// FormFile returns the first file for the provided form key.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if c.Request.MultipartForm == nil {
+		if err := c.Request.ParseMultipartForm(c.engine.MaxMultipartMemory); err != nil {
+			return nil, err
+		}
+	}
+	f, fh, err := c.Request.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return fh, err
+}
+
+// SaveUploadedFile uploads the form file to specific dst.
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// removeMultipartFiles removes the temporary files of the parsed multipart form, if any.
+func (c *Context) removeMultipartFiles() {
+	if c.Request != nil && c.Request.MultipartForm != nil {
+		c.Request.MultipartForm.RemoveAll()
+	}
+}
+
+// ClientIP implements one best effort algorithm to return the real client IP.
+// The Engine.RemoteIPHeaders, X-Forwarded-For and X-Real-IP by default, are used only
+// if the remote address is a trusted proxy, see Engine.SetTrustedProxies.
+func (c *Context) ClientIP() string {
+	remoteIP := net.ParseIP(c.RemoteIP())
+	if remoteIP == nil {
+		return ""
+	}
+
+	if c.engine.isTrustedProxy(remoteIP) {
+		for _, headerName := range c.engine.RemoteIPHeaders {
+			if ip, valid := c.engine.validateHeader(c.Request.Header.Get(headerName)); valid {
+				return ip
+			}
+		}
+	}
+	return remoteIP.String()
+}
+
+// RemoteIP parses the IP from Request.RemoteAddr, normalizes and returns the IP (without the port).
+func (c *Context) RemoteIP() string {
+	ip, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		return ""
+	}
+	return ip
+}
+
+// Logger returns a child logger of Engine.Logger carrying the request ID, route and client IP
+// of the request, so the messages of a request can be correlated.
+func (c *Context) Logger() Logger {
+	if c.logger == nil {
+		fields := make([]any, 0, 6)
+		if id := c.RequestID(); id != "" {
+			fields = append(fields, "request_id", id)
+		}
+		if c.fullPath != "" {
+			fields = append(fields, "route", c.fullPath)
+		}
+		fields = append(fields, "client_ip", c.ClientIP())
+		c.logger = c.engine.logger().With(fields...)
+	}
+	return c.logger
+}
+
+/************************************/
+/******** RESPONSE RENDERING ********/
+/************************************/
+
+// Stream sends a streaming response, step is called until it returns false or the client
+// is gone, and the response is flushed after every step. It returns true if the client
+// disconnected in the middle of the stream. It works for HTTP/1.1 and HTTP/2.
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+			keepOpen := step(c.Writer)
+			c.Writer.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
+
+// SetTrailer sets the trailer header sent after the response body, it needn't be
+// declared before the body is written. Trailers are sent by HTTP/2 and chunked HTTP/1.1
+// responses, e.g. a checksum of a streaming response.
+func (c *Context) SetTrailer(key, value string) {
+	c.Writer.Header().Set(http.TrailerPrefix+key, value)
+}
+
 /************************************/
 /**** HTTPS://PKG.GO.DEV/CONTEXT ****/
 /************************************/
@@ -0,0 +1,261 @@
+package fox
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createMultipartRequest(t *testing.T) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	assert.Nil(t, mw.WriteField("name", "fox"))
+	w, err := mw.CreateFormFile("file", "test.txt")
+	assert.Nil(t, err)
+	_, err = w.Write([]byte("hello fox"))
+	assert.Nil(t, err)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestContextFormFile(t *testing.T) {
+	c := New().allocateContext()
+	c.reset(httptest.NewRecorder(), createMultipartRequest(t))
+
+	f, err := c.FormFile("file")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "test.txt", f.Filename)
+	}
+
+	form, err := c.MultipartForm()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"fox"}, form.Value["name"])
+	}
+
+	_, err = c.FormFile("missing")
+	assert.Error(t, err)
+
+	dst := filepath.Join(t.TempDir(), "upload", "test.txt")
+	assert.NoError(t, c.SaveUploadedFile(f, dst))
+	data, err := os.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello fox", string(data))
+}
+
+func TestEngineRemoveMultipartFiles(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	router := New()
+	router.MaxMultipartMemory = 1 // the files are written to disk
+	router.POST("/upload", func(c *Context) string {
+		f, err := c.FormFile("file")
+		assert.NoError(t, err)
+		return f.Filename
+	})
+	router.POST("/panic", func(c *Context) {
+		_, err := c.FormFile("file")
+		assert.NoError(t, err)
+		panic("oops")
+	})
+
+	assertRemoved := func() {
+		entries, err := os.ReadDir(tmp)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, createMultipartRequest(t))
+	assert.Equal(t, "test.txt", w.Body.String())
+	assertRemoved()
+
+	req := createMultipartRequest(t)
+	req.URL.Path = "/panic"
+	assert.Panics(t, func() {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	})
+	assertRemoved()
+}
+
+func TestContextFormFileFailed(t *testing.T) {
+	c := New().allocateContext()
+	c.reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/upload", nil))
+
+	f, err := c.FormFile("file")
+	assert.Error(t, err)
+	assert.Nil(t, f)
+}
+
+func TestEngineBindUploadFile(t *testing.T) {
+	router := New()
+
+	type UploadArgs struct {
+		Name string                `pos:"form:name"`
+		File *multipart.FileHeader `pos:"file:file"`
+	}
+	router.POST("/upload", func(c *Context, args *UploadArgs) (string, error) {
+		return args.Name + ":" + args.File.Filename, nil
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, createMultipartRequest(t))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "fox:test.txt", w.Body.String())
+}
+
+func TestEngineBindUploadFileJSONTags(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	router := New()
+	router.MaxMultipartMemory = 1 // the files are written to disk
+
+	type UploadArgs struct {
+		Name   string                `json:"name" pos:"form:name"`
+		Avatar *multipart.FileHeader `json:"-" pos:"file:file"`
+	}
+	router.POST("/upload", func(c *Context, args *UploadArgs) (string, error) {
+		if args.Avatar == nil {
+			return args.Name, nil
+		}
+		// the engine's memory limit is used by the binding
+		entries, err := os.ReadDir(tmp)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, entries)
+		return args.Name + ":" + args.Avatar.Filename, nil
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, createMultipartRequest(t))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "fox:test.txt", w.Body.String())
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("name=fox"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "fox", w.Body.String())
+}
+
+func TestContextBody(t *testing.T) {
+	c := New().allocateContext()
+	c.reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello fox")))
+
+	body, err := c.Body()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello fox", string(body))
+
+	data, err := io.ReadAll(c.Request.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello fox", string(data))
+
+	body, err = c.Body()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello fox", string(body))
+
+	data, err = io.ReadAll(c.Request.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello fox", string(data))
+
+	c.reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello fox")))
+	c.limitBody(4)
+	_, err = c.Body()
+	assert.True(t, isBodyTooLarge(err))
+}
+
+func TestEngineReplayBody(t *testing.T) {
+	router := New()
+
+	type Args struct {
+		Name string `json:"name"`
+	}
+
+	var signed string
+	verify := func(c *Context, args *Args) error {
+		body, err := c.Body()
+		signed = string(body)
+		return err
+	}
+	router.POST("/users", verify, func(c *Context, args *Args, again *Args) string {
+		return args.Name + again.Name
+	})
+
+	w := PerformRequest(router, http.MethodPost, "/users", nil, strings.NewReader(`{"name":"fox"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "foxfox", w.Body.String())
+	assert.Equal(t, `{"name":"fox"}`, signed)
+}
+
+func TestEngineStreamBody(t *testing.T) {
+	router := New()
+
+	type Args struct {
+		Name string `pos:"query:name"`
+	}
+
+	// the body isn't buffered if no arguments struct binds it
+	router.POST("/upload", func(c *Context, args *Args) (string, error) {
+		assert.False(t, c.bodyBuffered)
+		data, err := io.ReadAll(c.Request.Body)
+		return args.Name + ":" + string(data), err
+	})
+
+	w := PerformRequest(router, http.MethodPost, "/upload?name=fox", nil, strings.NewReader("stream"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "fox:stream", w.Body.String())
+}
+
+func TestContextAbort(t *testing.T) {
+	router := New()
+
+	var called bool
+	auth := func(c *Context) {
+		if c.Request.Header.Get("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	}
+	router.GET("/", auth, func(c *Context) string {
+		called = true
+		return "ok"
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/", http.Header{})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, called)
+
+	w = PerformRequest(router, http.MethodGet, "/", http.Header{"Authorization": []string{"token"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, called)
+}
+
+func TestEngineBindInvalidValue(t *testing.T) {
+	router := New()
+
+	type Args struct {
+		Timeout time.Duration `pos:"query:timeout"`
+	}
+	router.GET("/jobs", func(c *Context, args *Args) string {
+		return args.Timeout.String()
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/jobs?timeout=1m", nil)
+	assert.Equal(t, "1m0s", w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/jobs?timeout=soon", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `easybind: can't bind "soon" to time.Duration`, w.Body.String())
+}
@@ -0,0 +1,220 @@
+package fox
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig defines the config for the CORS middleware.
+type CORSConfig struct {
+	// AllowOrigins is the list of origins a cross-domain request can be executed from.
+	// An origin is matched exactly, e.g. "https://example.com", or may contain one
+	// wildcard to match the subdomains, e.g. "https://*.example.com". "*" allows all origins.
+	AllowOrigins []string
+
+	// AllowOriginFunc is a custom function to validate the origin, it's called if the
+	// origin isn't matched by AllowOrigins.
+	AllowOriginFunc func(origin string) bool
+
+	// AllowMethods is the list of methods the client is allowed to use. If it's empty,
+	// the methods of the routes registered for the path are allowed, see Engine.HandleOPTIONS.
+	AllowMethods []string
+
+	// AllowHeaders is the list of non simple headers the client is allowed to use.
+	// If it's empty, the headers requested by the preflight request are allowed.
+	AllowHeaders []string
+
+	// ExposeHeaders is the list of headers which are safe to expose to the client.
+	ExposeHeaders []string
+
+	// AllowCredentials indicates whether the request can include user credentials like
+	// cookies, HTTP authentication or client side SSL certificates.
+	AllowCredentials bool
+
+	// MaxAge indicates how long the results of a preflight request can be cached.
+	MaxAge time.Duration
+
+	// AllowPrivateNetwork indicates whether a public origin can request the private
+	// network, see https://wicg.github.io/private-network-access/.
+	AllowPrivateNetwork bool
+}
+
+// CORS returns a middleware which implements the Cross-Origin Resource Sharing. Preflight
+// requests are answered with 204 No Content, or 403 Forbidden if the origin, the method
+// or the headers aren't allowed. Pass it to AutoOPTIONS too, so the preflight requests of
+// the paths without OPTIONS routes are answered:
+//
+//	cors := fox.CORS(fox.CORSConfig{
+//		AllowOrigins:     []string{"https://example.com", "https://*.example.com"},
+//		AllowCredentials: true,
+//		MaxAge:           12 * time.Hour,
+//	})
+//	router.Use(cors)
+//	router.AutoOPTIONS(cors)
+func CORS(config CORSConfig) HandlerFunc {
+	cors := newCORS(config)
+	return cors.handle
+}
+
+type cors struct {
+	allowAllOrigins     bool
+	allowOrigins        []string
+	wildcardOrigins     [][2]string
+	allowOriginFunc     func(origin string) bool
+	allowMethods        []string
+	allowHeaders        []string
+	allowCredentials    bool
+	allowPrivateNetwork bool
+	exposeHeaders       string
+	maxAge              string
+}
+
+func newCORS(config CORSConfig) *cors {
+	c := &cors{
+		allowOriginFunc:     config.AllowOriginFunc,
+		allowCredentials:    config.AllowCredentials,
+		allowPrivateNetwork: config.AllowPrivateNetwork,
+		exposeHeaders:       strings.Join(config.ExposeHeaders, ", "),
+	}
+
+	for _, origin := range config.AllowOrigins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			c.allowAllOrigins = true
+		} else if i := strings.IndexByte(origin, '*'); i >= 0 {
+			c.wildcardOrigins = append(c.wildcardOrigins, [2]string{origin[:i], origin[i+1:]})
+		} else {
+			c.allowOrigins = append(c.allowOrigins, origin)
+		}
+	}
+
+	for _, method := range config.AllowMethods {
+		c.allowMethods = append(c.allowMethods, strings.ToUpper(method))
+	}
+	for _, header := range config.AllowHeaders {
+		c.allowHeaders = append(c.allowHeaders, http.CanonicalHeaderKey(header))
+	}
+
+	if config.MaxAge > 0 {
+		c.maxAge = strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+	}
+	return c
+}
+
+func (cors *cors) handle(c *Context) {
+	origin := c.Request.Header.Get("Origin")
+	preflight := c.Request.Method == http.MethodOptions && c.Request.Header.Get("Access-Control-Request-Method") != ""
+
+	header := c.Writer.Header()
+	if !cors.allowAllOrigins || cors.allowCredentials {
+		header.Add("Vary", "Origin")
+	}
+	if preflight {
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		if cors.allowPrivateNetwork {
+			header.Add("Vary", "Access-Control-Request-Private-Network")
+		}
+	}
+
+	// not a cross-origin request
+	if origin == "" {
+		return
+	}
+
+	if !cors.allowOrigin(origin) {
+		if preflight {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+		return
+	}
+
+	if cors.allowAllOrigins && !cors.allowCredentials {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if cors.allowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		if cors.exposeHeaders != "" {
+			header.Set("Access-Control-Expose-Headers", cors.exposeHeaders)
+		}
+		return
+	}
+
+	methods := cors.allowMethods
+	if len(methods) == 0 {
+		methods = strings.Split(c.engine.allowedIn(c.scope, c.Request.URL.Path, http.MethodOptions), ", ")
+	}
+	method := strings.ToUpper(c.Request.Header.Get("Access-Control-Request-Method"))
+	if !containsString(methods, method) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	headers := parseHeaderList(c.Request.Header.Get("Access-Control-Request-Headers"))
+	if len(cors.allowHeaders) > 0 {
+		for _, h := range headers {
+			if !containsString(cors.allowHeaders, h) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+		}
+		headers = cors.allowHeaders
+	}
+
+	header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(headers) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if cors.maxAge != "" {
+		header.Set("Access-Control-Max-Age", cors.maxAge)
+	}
+	if cors.allowPrivateNetwork && c.Request.Header.Get("Access-Control-Request-Private-Network") == "true" {
+		header.Set("Access-Control-Allow-Private-Network", "true")
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// allowOrigin reports whether the origin is allowed.
+func (cors *cors) allowOrigin(origin string) bool {
+	if cors.allowAllOrigins {
+		return true
+	}
+
+	lower := strings.ToLower(origin)
+	if containsString(cors.allowOrigins, lower) {
+		return true
+	}
+	for _, w := range cors.wildcardOrigins {
+		if len(lower) > len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+	return cors.allowOriginFunc != nil && cors.allowOriginFunc(origin)
+}
+
+// parseHeaderList parses a comma separated list of header names into canonical keys.
+func parseHeaderList(value string) []string {
+	var headers []string
+	for _, h := range strings.Split(value, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			headers = append(headers, http.CanonicalHeaderKey(h))
+		}
+	}
+	return headers
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
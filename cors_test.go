@@ -0,0 +1,121 @@
+package fox
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	cors := CORS(CORSConfig{
+		AllowOrigins:        []string{"https://example.com", "https://*.example.org"},
+		AllowOriginFunc:     func(origin string) bool { return origin == "http://localhost:3000" },
+		AllowHeaders:        []string{"content-type", "X-Token"},
+		ExposeHeaders:       []string{"X-Total"},
+		AllowCredentials:    true,
+		MaxAge:              time.Hour,
+		AllowPrivateNetwork: true,
+	})
+
+	router := New()
+	router.Use(cors)
+	router.AutoOPTIONS(cors)
+	router.GET("/users", func(c *Context) string { return "users" })
+	router.POST("/users", func(c *Context) string { return "created" })
+
+	// simple request
+	header := http.Header{"Origin": []string{"https://example.com"}}
+	w := PerformRequest(router, http.MethodGet, "/users", header)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "users", w.Body.String())
+	assert.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "X-Total", w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal(t, []string{"Origin"}, w.Header().Values("Vary"))
+
+	// wildcard subdomain and predicate
+	for _, origin := range []string{"https://api.example.org", "https://a.b.example.org", "http://localhost:3000"} {
+		header = http.Header{"Origin": []string{origin}}
+		w = PerformRequest(router, http.MethodGet, "/users", header)
+		assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	// disallowed origin
+	for _, origin := range []string{"https://example.org", "https://evil.com", "http://api.example.org"} {
+		header = http.Header{"Origin": []string{origin}}
+		w = PerformRequest(router, http.MethodGet, "/users", header)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	// preflight answered by the automatic OPTIONS reply
+	header = http.Header{
+		"Origin":                                 []string{"https://example.com"},
+		"Access-Control-Request-Method":          []string{"POST"},
+		"Access-Control-Request-Headers":         []string{"Content-Type, x-token"},
+		"Access-Control-Request-Private-Network": []string{"true"},
+	}
+	w = PerformRequest(router, http.MethodOptions, "/users", header)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, OPTIONS, POST", w.Header().Get("Allow"))
+	assert.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, OPTIONS, POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, X-Token", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Private-Network"))
+	assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers",
+		"Access-Control-Request-Private-Network"}, w.Header().Values("Vary"))
+
+	// method not allowed
+	header.Set("Access-Control-Request-Method", "DELETE")
+	w = PerformRequest(router, http.MethodOptions, "/users", header)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+
+	// header not allowed
+	header.Set("Access-Control-Request-Method", "POST")
+	header.Set("Access-Control-Request-Headers", "X-Secret")
+	w = PerformRequest(router, http.MethodOptions, "/users", header)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// origin not allowed
+	header.Set("Access-Control-Request-Headers", "X-Token")
+	header.Set("Origin", "https://evil.com")
+	w = PerformRequest(router, http.MethodOptions, "/users", header)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	// plain OPTIONS request
+	w = PerformRequest(router, http.MethodOptions, "/users", http.Header{})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "GET, OPTIONS, POST", w.Header().Get("Allow"))
+}
+
+func TestCORSAllowAllOrigins(t *testing.T) {
+	router := New()
+	router.Use(CORS(CORSConfig{AllowOrigins: []string{"*"}}))
+	router.GET("/", func(c *Context) string { return "ok" })
+	router.OPTIONS("/", func(c *Context) string { return "options" })
+
+	header := http.Header{"Origin": []string{"https://example.com"}}
+	w := PerformRequest(router, http.MethodGet, "/", header)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Empty(t, w.Header().Values("Vary"))
+
+	// preflight answered by the middleware of the OPTIONS route
+	header.Set("Access-Control-Request-Method", "GET")
+	header.Set("Access-Control-Request-Headers", "X-Custom")
+	w = PerformRequest(router, http.MethodOptions, "/", header)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "X-Custom", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+
+	w = PerformRequest(router, http.MethodOptions, "/", http.Header{})
+	assert.Equal(t, "options", w.Body.String())
+}
@@ -0,0 +1,246 @@
+package fox
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+// The errors of the requests rejected by the CSRF middleware.
+var (
+	ErrCSRFTokenMissing = errors.New("csrf: token is missing")
+	ErrCSRFTokenInvalid = errors.New("csrf: token is invalid")
+	ErrCSRFOrigin       = errors.New("csrf: origin is not allowed")
+)
+
+// csrfSecretLength is the length of the secret of the CSRF tokens.
+const csrfSecretLength = 32
+
+// csrfSessionKey is the key of the secret in the session.
+const csrfSessionKey = "_csrf"
+
+// CSRFConfig defines the config for the CSRF middleware.
+type CSRFConfig struct {
+	// Session keeps the secret of the tokens in the session of the Sessions middleware,
+	// the synchronizer token pattern. The secret is kept in a cookie by default, the
+	// double submit cookie pattern.
+	Session bool
+
+	// Header is the request header of the token, X-CSRF-Token by default.
+	Header string
+
+	// FormField is the form field of the token, csrf_token by default.
+	FormField string
+
+	// TrustedOrigins are the origins allowed to post besides the host of the request,
+	// e.g. "https://app.example.com".
+	TrustedOrigins []string
+
+	// Cookie is the cookie of the secret, the name is "_csrf", the path is "/", and it's
+	// HttpOnly and SameSite=Lax by default. It's Secure if Cookie.Secure is set or the
+	// request is HTTPS. Behind a proxy terminating TLS the requests are plain HTTP, so set
+	// Cookie.Secure to keep the cookie off plain HTTP.
+	Cookie http.Cookie
+
+	// Optional. Skip reports whether the request isn't checked, e.g. the API authenticated by
+	// a bearer token.
+	Skip func(c *Context) bool
+
+	// Optional. ErrorHandler is called instead of the default 403 Forbidden response when
+	// the request is rejected.
+	ErrorHandler func(c *Context, err error)
+}
+
+// CSRF returns a middleware which protects the unsafe requests, e.g. POST, PUT, PATCH and
+// DELETE, against the cross-site request forgery. The unsafe requests must send the token
+// of Context.CSRFToken in the X-CSRF-Token header or the csrf_token form field, and their
+// Origin or Referer must be the host of the request or a trusted origin. The token is
+// returned by the csrfToken func of the templates:
+//
+//	<form method="post">
+//		<input type="hidden" name="csrf_token" value="{{ csrfToken }}">
+//	</form>
+//
+// The func works with the templates loaded by LoadHTMLGlob, LoadHTMLFiles and
+// SetHTMLTemplate, their clones are pooled so a render costs no copy of the templates.
+// A render.HTML Template must be parsed with Engine.FuncMap and not be executed before,
+// it's cloned on every render, otherwise csrfToken returns "", pass Context.CSRFToken in
+// the data instead.
+func CSRF(config CSRFConfig) HandlerFunc {
+	if config.Header == "" {
+		config.Header = "X-CSRF-Token"
+	}
+	if config.FormField == "" {
+		config.FormField = "csrf_token"
+	}
+	if config.Cookie.Name == "" {
+		config.Cookie.Name = "_csrf"
+	}
+	if config.Cookie.Path == "" {
+		config.Cookie.Path = "/"
+	}
+	if config.Cookie.SameSite == 0 {
+		config.Cookie.SameSite = http.SameSiteLaxMode
+	}
+	config.Cookie.HttpOnly = true
+
+	trusted := make(map[string]bool, len(config.TrustedOrigins))
+	for _, origin := range config.TrustedOrigins {
+		trusted[origin] = true
+	}
+
+	return func(c *Context) {
+		if config.Skip != nil && config.Skip(c) {
+			return
+		}
+
+		secret := loadCSRFSecret(c, config)
+		if secret == nil {
+			secret = make([]byte, csrfSecretLength)
+			if _, err := rand.Read(secret); err != nil {
+				panic(err)
+			}
+			saveCSRFSecret(c, config, secret)
+		}
+		c.csrfSecret = secret
+		c.setTemplateFunc("csrfToken", c.CSRFToken)
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			return
+		}
+
+		err := checkCSRFOrigin(c, trusted)
+		if err == nil {
+			err = checkCSRFToken(c, config, secret)
+		}
+		if err == nil {
+			return
+		}
+
+		if config.ErrorHandler != nil {
+			config.ErrorHandler(c, err)
+			c.Abort()
+			return
+		}
+		c.Writer.Header()["Content-Type"] = mimePlain
+		c.AbortWithStatus(http.StatusForbidden)
+		c.Writer.WriteString(err.Error())
+	}
+}
+
+// CSRFToken returns the CSRF token of the request, "" if the CSRF middleware isn't used.
+// The token is masked by a random pad every call, so it can't be guessed by the
+// compression of the responses (BREACH).
+func (c *Context) CSRFToken() string {
+	if c.csrfSecret == nil {
+		return ""
+	}
+
+	token := make([]byte, 2*csrfSecretLength)
+	if _, err := rand.Read(token[:csrfSecretLength]); err != nil {
+		panic(err)
+	}
+	for i, b := range c.csrfSecret {
+		token[csrfSecretLength+i] = token[i] ^ b
+	}
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// unmaskCSRFToken returns the secret of the token.
+func unmaskCSRFToken(token string) []byte {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) != 2*csrfSecretLength {
+		return nil
+	}
+	secret := make([]byte, csrfSecretLength)
+	for i := range secret {
+		secret[i] = data[i] ^ data[csrfSecretLength+i]
+	}
+	return secret
+}
+
+func loadCSRFSecret(c *Context, config CSRFConfig) []byte {
+	var value string
+	if config.Session {
+		value, _ = c.Session().Get(csrfSessionKey).(string)
+	} else if cookie, err := c.Request.Cookie(config.Cookie.Name); err == nil {
+		value = cookie.Value
+	}
+
+	secret, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(secret) != csrfSecretLength {
+		return nil
+	}
+	return secret
+}
+
+func saveCSRFSecret(c *Context, config CSRFConfig, secret []byte) {
+	value := base64.RawURLEncoding.EncodeToString(secret)
+	if config.Session {
+		c.Session().Set(csrfSessionKey, value)
+		return
+	}
+
+	cookie := config.Cookie
+	cookie.Value = value
+	cookie.Secure = cookie.Secure || c.Request.TLS != nil
+	http.SetCookie(c.Writer, &cookie)
+}
+
+// checkCSRFOrigin checks the Origin header, or the Referer header if there is no Origin.
+// The HTTPS requests without both are rejected, the browsers send at least one of them.
+func checkCSRFOrigin(c *Context, trusted map[string]bool) error {
+	origin := c.Request.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		referer := c.Request.Header.Get("Referer")
+		if referer == "" {
+			if c.Request.TLS != nil {
+				return ErrCSRFOrigin
+			}
+			return nil
+		}
+		origin = referer
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return ErrCSRFOrigin
+	}
+	if u.Host == c.Request.Host || trusted[u.Scheme+"://"+u.Host] {
+		return nil
+	}
+	return ErrCSRFOrigin
+}
+
+// checkCSRFToken checks the token of the header or the form field, the body is buffered,
+// so it can still be bound by the handlers.
+func checkCSRFToken(c *Context, config CSRFConfig, secret []byte) error {
+	token := c.Request.Header.Get(config.Header)
+	if token == "" {
+		mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+		switch mediaType {
+		case "multipart/form-data":
+			if form, err := c.MultipartForm(); err == nil && len(form.Value[config.FormField]) > 0 {
+				token = form.Value[config.FormField][0]
+			}
+		case "application/x-www-form-urlencoded":
+			if body, err := c.Body(); err == nil {
+				values, _ := url.ParseQuery(string(body))
+				token = values.Get(config.FormField)
+			}
+		}
+	}
+	if token == "" {
+		return ErrCSRFTokenMissing
+	}
+
+	if subtle.ConstantTimeCompare(unmaskCSRFToken(token), secret) != 1 {
+		return ErrCSRFTokenInvalid
+	}
+	return nil
+}
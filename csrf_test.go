@@ -0,0 +1,209 @@
+package fox
+
+import (
+	"bytes"
+	"crypto/tls"
+	"html/template"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/miclle/fox/render"
+	"github.com/miclle/fox/sessions"
+)
+
+// csrfForm returns the cookie and the token of the form rendered by GET /form.
+func csrfForm(t *testing.T, router *Engine, header http.Header) (string, string) {
+	w := PerformRequest(router, http.MethodGet, "/form", header)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	matches := regexp.MustCompile(`value="([^"]+)"`).FindStringSubmatch(w.Body.String())
+	assert.Len(t, matches, 2, w.Body.String())
+
+	var cookie string
+	if cookies := w.Result().Cookies(); len(cookies) > 0 {
+		cookie = cookies[0].Name + "=" + cookies[0].Value
+	}
+	return cookie, matches[1]
+}
+
+func TestCSRF(t *testing.T) {
+	router := New()
+	router.SetHTMLTemplate(template.Must(template.New("form").Funcs(router.FuncMap).Parse(
+		`<input type="hidden" name="csrf_token" value="{{ csrfToken }}">`)))
+	router.Use(CSRF(CSRFConfig{TrustedOrigins: []string{"https://app.example.com"}}))
+	router.GET("/form", func(c *Context) render.HTML {
+		return render.HTML{Name: "form"}
+	})
+	type Args struct {
+		Name string `pos:"form:name"`
+	}
+	router.POST("/submit", func(c *Context, args *Args) string {
+		return "submitted " + args.Name
+	})
+
+	cookie, token := csrfForm(t, router, http.Header{})
+	assert.True(t, strings.HasPrefix(cookie, "_csrf="))
+
+	// the token is masked every time, the secret is kept
+	cookie2, token2 := csrfForm(t, router, http.Header{"Cookie": []string{cookie}})
+	assert.Empty(t, cookie2)
+	assert.NotEqual(t, token, token2)
+
+	// form field
+	header := http.Header{
+		"Cookie":       []string{cookie},
+		"Content-Type": []string{"application/x-www-form-urlencoded"},
+		"Origin":       []string{"http://example.com"},
+	}
+	body := url.Values{"csrf_token": []string{token2}, "name": []string{"fox"}}.Encode()
+	w := PerformRequest(router, http.MethodPost, "/submit", header, strings.NewReader(body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "submitted fox", w.Body.String())
+
+	// header
+	header = http.Header{"Cookie": []string{cookie}, "X-Csrf-Token": []string{token}, "Origin": []string{"https://app.example.com"}}
+	w = PerformRequest(router, http.MethodPost, "/submit", header)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// multipart form
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	assert.NoError(t, mw.WriteField("csrf_token", token))
+	assert.NoError(t, mw.WriteField("name", "multipart"))
+	assert.NoError(t, mw.Close())
+	header = http.Header{"Cookie": []string{cookie}, "Content-Type": []string{mw.FormDataContentType()}}
+	w = PerformRequest(router, http.MethodPost, "/submit", header, &buf)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "submitted multipart", w.Body.String())
+
+	// missing or invalid token
+	w = PerformRequest(router, http.MethodPost, "/submit", http.Header{"Cookie": []string{cookie}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "csrf: token is missing", w.Body.String())
+
+	_, otherToken := csrfForm(t, router, http.Header{})
+	header = http.Header{"Cookie": []string{cookie}, "X-Csrf-Token": []string{otherToken}}
+	w = PerformRequest(router, http.MethodPost, "/submit", header)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "csrf: token is invalid", w.Body.String())
+
+	header = http.Header{"X-Csrf-Token": []string{token}}
+	w = PerformRequest(router, http.MethodPost, "/submit", header)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// cross origin
+	for _, h := range []http.Header{
+		{"Origin": []string{"https://evil.com"}},
+		{"Referer": []string{"https://evil.com/form"}},
+		{"Origin": []string{"null"}, "Referer": []string{"https://app.example.com.evil.com/"}},
+	} {
+		h.Set("Cookie", cookie)
+		h.Set("X-Csrf-Token", token)
+		w = PerformRequest(router, http.MethodPost, "/submit", h)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "csrf: origin is not allowed", w.Body.String())
+	}
+
+	header = http.Header{"Cookie": []string{cookie}, "X-Csrf-Token": []string{token}, "Referer": []string{"http://example.com/form"}}
+	w = PerformRequest(router, http.MethodPost, "/submit", header)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// HTTPS requests must have an Origin or a Referer
+	req := httptest.NewRequest(http.MethodPost, "/submit", nil)
+	req.TLS = &tls.ConnectionState{}
+	req.Header.Set("Cookie", cookie)
+	req.Header.Set("X-Csrf-Token", token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCSRFSession(t *testing.T) {
+	router := New()
+	router.Use(Sessions("session", sessions.NewMemoryStore(time.Hour)))
+	router.Use(CSRF(CSRFConfig{
+		Session: true,
+		ErrorHandler: func(c *Context, err error) {
+			c.Writer.WriteHeader(http.StatusBadRequest)
+		},
+	}))
+	router.GET("/form", func(c *Context) render.HTML {
+		tmpl := template.Must(template.New("").Funcs(c.engine.FuncMap).Parse(`value="{{ csrfToken }}"`))
+		return render.HTML{Template: tmpl}
+	})
+	router.POST("/submit", func(c *Context) string {
+		return "submitted"
+	})
+
+	cookie, token := csrfForm(t, router, http.Header{})
+	assert.True(t, strings.HasPrefix(cookie, "session="))
+
+	header := http.Header{"Cookie": []string{cookie}, "X-Csrf-Token": []string{token}}
+	w := PerformRequest(router, http.MethodPost, "/submit", header)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "submitted", w.Body.String())
+
+	w = PerformRequest(router, http.MethodPost, "/submit", http.Header{"X-Csrf-Token": []string{token}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCSRFSecureCookie(t *testing.T) {
+	perform := func(config CSRFConfig, tls *tls.ConnectionState) *http.Cookie {
+		router := New()
+		router.Use(CSRF(config))
+		router.GET("/", func(c *Context) string { return c.CSRFToken() })
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = tls
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if cookies := w.Result().Cookies(); assert.Len(t, cookies, 1) {
+			return cookies[0]
+		}
+		return &http.Cookie{}
+	}
+
+	assert.False(t, perform(CSRFConfig{}, nil).Secure)
+	assert.True(t, perform(CSRFConfig{}, &tls.ConnectionState{}).Secure)
+	// behind a proxy terminating TLS
+	assert.True(t, perform(CSRFConfig{Cookie: http.Cookie{Secure: true}}, nil).Secure)
+}
+
+func TestCSRFTokenWithoutMiddleware(t *testing.T) {
+	router := New()
+	router.SetHTMLTemplate(template.Must(template.New("form").Funcs(router.FuncMap).Parse(`[{{ csrfToken }}]`)))
+	router.GET("/", func(c *Context) render.HTML {
+		assert.Empty(t, c.CSRFToken())
+		return render.HTML{Name: "form"}
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/", nil)
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+func TestCSRFExecutedTemplate(t *testing.T) {
+	router := New()
+	tmpl := template.Must(template.New("").Funcs(router.FuncMap).Parse(`[{{ csrfToken }}]`))
+	assert.NoError(t, tmpl.Execute(io.Discard, nil))
+
+	router.Use(CSRF(CSRFConfig{}))
+	router.GET("/form", func(c *Context) render.HTML {
+		return render.HTML{Template: tmpl}
+	})
+
+	// the executed template can't be cloned, its csrfToken stays the placeholder
+	output := captureOutput(t, DebugMode, func() {
+		w := PerformRequest(router, http.MethodGet, "/form", nil)
+		assert.Equal(t, "[]", w.Body.String())
+	})
+	assert.Contains(t, output, "The template funcs of the request, e.g. csrfToken, are not bound")
+}
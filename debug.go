@@ -0,0 +1,86 @@
+package fox
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// DebugPrintRouteFunc indicates debug log output format of the registered routes.
+var DebugPrintRouteFunc func(httpMethod, absolutePath, handlerName string, nuHandlers int)
+
+// IsDebugging returns true if the framework is running in debug mode.
+// Use SetMode(fox.ReleaseMode) to disable debug mode.
+func IsDebugging() bool {
+	return engineMode == DebugMode
+}
+
+func debugPrintRoute(logger Logger, httpMethod, absolutePath string, handlers HandlersChain) {
+	if IsDebugging() && len(handlers) > 0 {
+		nuHandlers := len(handlers)
+		handlerName := getFunctionName(handlers[nuHandlers-1])
+		if DebugPrintRouteFunc == nil {
+			debugPrint(logger, "%-6s %-25s --> %s (%d handlers)\n", httpMethod, absolutePath, handlerName, nuHandlers)
+		} else {
+			DebugPrintRouteFunc(httpMethod, absolutePath, handlerName, nuHandlers)
+		}
+	}
+}
+
+func debugPrintLoadTemplate(logger Logger, tmpl *template.Template) {
+	if IsDebugging() {
+		var buf strings.Builder
+		for _, tmpl := range tmpl.Templates() {
+			buf.WriteString("\t- ")
+			buf.WriteString(tmpl.Name())
+			buf.WriteString("\n")
+		}
+		debugPrint(logger, "Loaded HTML Templates (%d): \n%s\n", len(tmpl.Templates()), buf.String())
+	}
+}
+
+// debugPrint writes the debug output through logger in debug mode, DefaultLogger is used
+// if logger is nil.
+func debugPrint(logger Logger, format string, values ...any) {
+	if IsDebugging() {
+		if logger == nil {
+			logger = DefaultLogger()
+		}
+		logger.Debug(strings.TrimRight(fmt.Sprintf(format, values...), "\n"))
+	}
+}
+
+// debugPrintWARNING writes the warnings of debug mode through logger.
+func debugPrintWARNING(logger Logger, format string, values ...any) {
+	if IsDebugging() {
+		if logger == nil {
+			logger = DefaultLogger()
+		}
+		logger.Warn(strings.TrimRight(fmt.Sprintf(format, values...), "\n"))
+	}
+}
+
+func debugPrintWARNINGNew(logger Logger) {
+	debugPrintWARNING(logger, `Running in "debug" mode. Switch to "release" mode in production.
+ - using env:	export FOX_MODE=release
+ - using code:	fox.SetMode(fox.ReleaseMode)
+
+`)
+}
+
+func debugPrintWARNINGUse(logger Logger) {
+	debugPrintWARNING(logger, `Middleware is added after routes are registered,
+the registered routes don't run it. Call Use before adding routes.
+
+`)
+}
+
+func debugPrintWARNINGSetHTMLTemplate(logger Logger) {
+	debugPrintWARNING(logger, `Since SetHTMLTemplate() is NOT thread-safe. It should only be called
+at initialization. ie. before any route is registered or the router is listening in a socket:
+
+	router := fox.New()
+	router.SetHTMLTemplate(template) // << good place
+
+`)
+}
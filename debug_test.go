@@ -0,0 +1,150 @@
+package fox
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/miclle/fox/render"
+)
+
+// captureOutput runs f in mode and returns what was written to DefaultWriter.
+func captureOutput(t *testing.T, mode string, f func()) string {
+	buffer := new(bytes.Buffer)
+	oldWriter, oldMode := DefaultWriter, Mode()
+	DefaultWriter = buffer
+	SetMode(mode)
+	defer func() {
+		DefaultWriter = oldWriter
+		SetMode(oldMode)
+	}()
+
+	f()
+	return buffer.String()
+}
+
+func TestSetMode(t *testing.T) {
+	defer SetMode(TestMode)
+
+	SetMode(ReleaseMode)
+	assert.Equal(t, ReleaseMode, Mode())
+	assert.False(t, IsDebugging())
+
+	SetMode("")
+	assert.Equal(t, DebugMode, Mode())
+	assert.True(t, IsDebugging())
+
+	os.Setenv(EnvFoxMode, ReleaseMode)
+	defer os.Unsetenv(EnvFoxMode)
+	SetMode(os.Getenv(EnvFoxMode))
+	assert.Equal(t, ReleaseMode, Mode())
+}
+
+func TestDebugPrintRoute(t *testing.T) {
+	output := captureOutput(t, DebugMode, func() {
+		router := New()
+		router.GET("/users/:id", func(c *Context) {}, handlerNameTest)
+	})
+	assert.Contains(t, output, "[WARNING] Running in \"debug\" mode")
+	assert.Regexp(t, `\[FOX-debug\] GET    /users/:id\s+--> github.com/miclle/fox.handlerNameTest \(2 handlers\)`, output)
+
+	output = captureOutput(t, ReleaseMode, func() {
+		router := New()
+		router.GET("/users/:id", handlerNameTest)
+	})
+	assert.Empty(t, output)
+}
+
+func TestDebugPrintRouteFunc(t *testing.T) {
+	var routes []string
+	DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
+		routes = append(routes, httpMethod+" "+absolutePath+" "+handlerName)
+	}
+	defer func() { DebugPrintRouteFunc = nil }()
+
+	captureOutput(t, DebugMode, func() {
+		New().POST("/users", handlerNameTest)
+	})
+	assert.Equal(t, []string{"POST /users github.com/miclle/fox.handlerNameTest"}, routes)
+}
+
+func TestDebugPrintWARNINGUse(t *testing.T) {
+	output := captureOutput(t, DebugMode, func() {
+		router := New()
+		router.Use(func(c *Context) {})
+		api := router.Group("/api")
+		api.Use(func(c *Context) {})
+		router.GET("/ping", handlerNameTest)
+	})
+	assert.NotContains(t, output, "Middleware is added after routes")
+
+	output = captureOutput(t, DebugMode, func() {
+		router := New()
+		api := router.Group("/api")
+		api.Group("/v1").GET("/ping", handlerNameTest)
+		router.Group("/admin").Use(func(c *Context) {})
+		router.Use(func(c *Context) {})
+	})
+	assert.Contains(t, output, "Middleware is added after routes")
+
+	output = captureOutput(t, ReleaseMode, func() {
+		router := New()
+		router.GET("/ping", handlerNameTest)
+		router.Use(func(c *Context) {})
+	})
+	assert.Empty(t, output)
+}
+
+func TestDebugPrintWARNINGWriteHeader(t *testing.T) {
+	router := New()
+	router.GET("/", func(c *Context) {
+		c.Writer.WriteHeader(http.StatusCreated)
+		c.Writer.WriteHeaderNow()
+		c.Writer.WriteHeader(http.StatusAccepted)
+	})
+
+	output := captureOutput(t, DebugMode, func() {
+		w := PerformRequest(router, http.MethodGet, "/", nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+	assert.Contains(t, output, "Headers were already written. Wanted to override status code 201 with 202")
+
+	output = captureOutput(t, ReleaseMode, func() {
+		PerformRequest(router, http.MethodGet, "/", nil)
+	})
+	assert.Empty(t, output)
+}
+
+func TestDebugPrintWARNINGMissingTemplate(t *testing.T) {
+	router := New()
+	router.PanicHandler = func(w http.ResponseWriter, r *http.Request, rcv any) {}
+	router.GET("/", func() render.HTML {
+		return render.HTML{Name: "index.tmpl"}
+	})
+
+	output := captureOutput(t, DebugMode, func() {
+		PerformRequest(router, http.MethodGet, "/", nil)
+	})
+	assert.Contains(t, output, "No HTML templates are loaded")
+
+	captureOutput(t, ReleaseMode, func() {
+		router.LoadHTMLGlob("./testdata/template/raw.tmpl")
+	})
+	output = captureOutput(t, DebugMode, func() {
+		w := PerformRequest(router, http.MethodGet, "/", nil)
+		assert.Empty(t, w.Body.String())
+	})
+	assert.Contains(t, output, `HTML template "index.tmpl" is not defined`)
+
+	// release mode renders the same way without the warnings
+	output = captureOutput(t, ReleaseMode, func() {
+		w := PerformRequest(router, http.MethodGet, "/", nil)
+		assert.Empty(t, w.Body.String())
+	})
+	assert.NotContains(t, output, "WARNING")
+}
+
+func handlerNameTest(c *Context) {}
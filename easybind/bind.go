@@ -3,6 +3,8 @@ package easybind
 import (
 	"context"
 	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"reflect"
 	"strings"
@@ -20,26 +22,59 @@ const (
 	inTagBody   = "body"
 	inTagForm   = "form"
 	inTagHeader = "header"
+	inTagFile   = "file"
+	inTagCookie = "cookie"
+	inTagCtx    = "ctx"
 
-	tagNameIn = "pos"
-	tagSep    = ","
+	tagNameIn      = "pos"
+	tagNameDefault = "default"
+	tagSep         = ","
 )
 
-// Bind bind params from Path, Query, Body, Form. Donot support binary stream(files, images etc.)
+// defaultMultipartMemory is the maxMemory argument passed to http.Request.ParseMultipartForm
+// if the Options have no MaxMultipartMemory.
+const defaultMultipartMemory = 32 << 20 // 32 MB
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// Bind bind params from Path, Query, Body, Form and multipart files.
 // Support Tag `pos`, specified that where we can get this value, only support one
 // - path: from url path, don't support nested struct
-// - query: from url query, don't support nested struct
+// - query: from url query, support nested struct, map and slice, e.g. filter[status]=x, page.size=20, items[0].id=1
 // - body: from request's body, default use json, support nested struct
-// - form: from request form
+// - form: from request form, urlencoded or multipart, support nested struct like query
+// - file: from multipart form files, field type must be *multipart.FileHeader or []*multipart.FileHeader
+// - cookie: from request cookie
+// - ctx: from the value stored by middleware, pathQueryier implements `Get(string) (any, bool)`
 // - required: this value is not null
-// pathQueryier get variables from path, GET /api/v1/users/:id , get id
+// - style, explode: OpenAPI serialization of slices, e.g. `pos:"query:ids,style=pipeDelimited"` binds ids=1|2,
+// query and form accept ids=1&ids=2 and ids[]=1 by default, path and header accept ids=1,2, the split
+// values are trimmed, so a []string header binds "X-Tags: a, b" as [a b]. See Parameters.
+// Tag `default` specified the value used when the source has no value.
+// Tags `time_format`, `time_location` and `time_unit` (s, ms, us, ns for epoch) specified
+// how time.Time fields are parsed, the global TimeFormats in time.Local are used by default.
+// pathQueryier get variables from path, GET /api/v1/users/:id , get id, an Options
+// in pathQueryier configures the JSON body decoding.
 /*
 type Example struct {
-	ID   string `json:"id"   pos:"path:id"`             // path value default is required
-	Name string `json:"name" pos:"query:name,required"` // query specified that get
+	ID      string                `json:"id"   pos:"path:id"`             // path value default is required
+	Name    string                `json:"name" pos:"query:name,required"` // query specified that get
+	Limit   int                   `pos:"query:limit" default:"20"`        // 20 when the query has no limit
+	Session string                `pos:"cookie:session"`                  // cookie value
+	User    *User                 `pos:"ctx:user"`                        // value set by middleware
+	Avatar  *multipart.FileHeader `pos:"file:avatar"`                     // multipart file
 }
 */
 func Bind(req *http.Request, params interface{}, pathQueryier ...interface{}) (err error) {
+	return BindFrom(NewRequestSource(req, pathQueryier...), params, getOptions(pathQueryier...))
+}
+
+// BindFrom binds params from src with the same tags as Bind, so the arguments structs
+// can be bound from message-queue payloads, CLI flags and so on.
+func BindFrom(src Source, params interface{}, opts ...Options) (err error) {
 	paramsVal := reflect.ValueOf(params)
 	if paramsVal.Kind() != reflect.Ptr {
 		err = errors.New("can't bind to nonpointer value")
@@ -62,15 +97,18 @@ func Bind(req *http.Request, params interface{}, pathQueryier ...interface{}) (e
 	var (
 		typ         = paramsVal.Type()
 		wg          = sync.WaitGroup{}
+		errOnce     = sync.Once{}
 		ctx, cancel = context.WithCancel(context.Background())
 		easy        = &easyReq{
-			ctx:          ctx,
-			req:          req,
-			once:         &sync.Once{},
-			pathQueryier: pathQueryier,
+			ctx: ctx,
+			src: src,
 		}
 	)
 
+	if len(opts) > 0 {
+		easy.opts = opts[0]
+	}
+
 	defer cancel()
 
 	for i := 0; i < paramsVal.NumField(); i++ {
@@ -78,29 +116,42 @@ func Bind(req *http.Request, params interface{}, pathQueryier ...interface{}) (e
 		fieldType := typ.Field(i)
 		wg.Add(1)
 		go func() {
-			err = easy.bindFieldWithCtx(field, fieldType)
-			if err != nil {
-				cancel()
+			defer wg.Done()
+			if fieldErr := easy.bindFieldWithCtx(field, fieldType); fieldErr != nil {
+				errOnce.Do(func() {
+					err = fieldErr
+					cancel()
+				})
 			}
-			wg.Done()
 		}()
 	}
 
 	wg.Wait()
 
-	if easy.hasJSONBody {
-		err = json.NewDecoder(req.Body).Decode(params)
+	// the form bodies are bound by the form and file locations, the json tags are only names
+	if err != nil || !easy.hasJSONBody || isForm(firstValue(src.Header("Content-Type"))) {
+		return
 	}
 
-	return
+	body, err := src.Body()
+	if err != nil || body == nil {
+		return
+	}
+	return decodeJSON(body, params, easy.opts)
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
 }
 
 type easyReq struct {
-	ctx          context.Context
-	once         *sync.Once
-	pathQueryier []interface{}
-	req          *http.Request
-	hasJSONBody  bool
+	ctx         context.Context
+	src         Source
+	opts        Options
+	hasJSONBody bool
 }
 
 func (e *easyReq) bindFieldWithCtx(field reflect.Value, fieldType reflect.StructField) (err error) {
@@ -127,7 +178,7 @@ func (e *easyReq) bindFieldWithCtx(field reflect.Value, fieldType reflect.Struct
 func (e *easyReq) bindField(field reflect.Value, fieldType reflect.StructField, errCh chan error) {
 	if fieldType.Anonymous {
 		r := reflect.New(field.Type())
-		err := Bind(e.req, r.Interface(), e.pathQueryier...)
+		err := BindFrom(e.src, r.Interface(), e.opts)
 		if err != nil {
 			errCh <- err
 			return
@@ -146,42 +197,134 @@ func (e *easyReq) bindField(field reflect.Value, fieldType reflect.StructField,
 
 	switch loc {
 	case inTagPath:
-		pathVal := getValueFromPath(name, e.pathQueryier...)
-		values = append(values, pathVal)
+		if pathVal := e.src.Path(name); pathVal != "" {
+			values = append(values, pathVal)
+		}
 	case inTagQuery:
-		values = e.req.URL.Query()[name]
+		query := e.src.Query()
+		if isNested(field.Type()) {
+			if err := bindNested(field, name, query); err != nil {
+				errCh <- err
+			}
+			return
+		}
+		values = lookupValues(query, name)
 	case inTagHeader:
-		values = e.req.Header.Values(name)
+		values = e.src.Header(name)
 	case inTagForm:
-		e.once.Do(func() {
-			e.req.ParseForm()
-		})
+		form, err := e.src.Form()
+		if err != nil {
+			errCh <- err
+			return
+		}
 
-		values = e.req.PostForm[name]
+		if isNested(field.Type()) {
+			if err := bindNested(field, name, form); err != nil {
+				errCh <- err
+			}
+			return
+		}
+		values = lookupValues(form, name)
+	case inTagFile:
+		if err := e.bindFile(field, name); err != nil {
+			errCh <- err
+		}
+		return
+	case inTagCookie:
+		if cookie, ok := e.src.Cookie(name); ok {
+			values = append(values, cookie)
+		}
+	case inTagCtx:
+		if ok, err := bindKeyValue(field, name, e.src); ok || err != nil {
+			if err != nil {
+				errCh <- err
+			}
+			return
+		}
 	}
 
-	var reflectVal reflect.Value
-	switch len(values) {
-	case 0:
-		return
-	case 1:
-		reflectVal = BindValue(values[0], field.Type())
-	default:
-		reflectVal = sliceBinder(values, field.Type())
+	if len(values) == 0 {
+		if def, ok := fieldType.Tag.Lookup(tagNameDefault); ok {
+			values = append(values, def)
+		}
 	}
 
-	if reflectVal.Type().ConvertibleTo(field.Type()) {
-		if reflectVal.Type() == field.Type() {
-			if field.Type().Kind() == reflect.Array || field.Type().Kind() == reflect.Slice {
-				field.Set(reflect.AppendSlice(field, reflectVal))
-			} else {
-				field.Set(reflectVal)
+	if isStyledSlice(field.Type()) {
+		opts, err := getInTagOptions(fieldType)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		values = splitValues(values, opts)
+	}
+
+	if isTimeField(field.Type()) {
+		opts, err := getTimeOptions(fieldType)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		if opts != nil {
+			if err := opts.bind(field, values); err != nil {
+				errCh <- err
 			}
-		} else {
-			field.Set(reflectVal.Convert(field.Type()))
+			return
 		}
 	}
 
+	if err := setValues(field, values); err != nil {
+		errCh <- err
+	}
+}
+
+// setValues binds the string values to field, slice fields get all the values appended.
+// It returns the error of an invalid value of the built-in types and the unmarshalers.
+func setValues(field reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if field.Kind() == reflect.Slice && !hasTypeBinder(field.Type()) {
+		slices, err := sliceBinder(values, field.Type())
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.AppendSlice(field, slices))
+		return nil
+	}
+
+	reflectVal, err := bindValue(values[0], field.Type())
+	if err != nil {
+		return err
+	}
+	if reflectVal.Type().ConvertibleTo(field.Type()) {
+		field.Set(reflectVal.Convert(field.Type()))
+	}
+	return nil
+}
+
+func (e *easyReq) bindFile(field reflect.Value, name string) error {
+	if field.Type() != fileHeaderType && field.Type() != fileHeaderSliceType {
+		return errors.New("file field " + name + " must be *multipart.FileHeader or []*multipart.FileHeader")
+	}
+
+	getter, ok := e.src.(fileGetter)
+	if !ok {
+		return nil
+	}
+
+	files, err := getter.File(name)
+	if err != nil || len(files) == 0 {
+		return err
+	}
+
+	if field.Type() == fileHeaderType {
+		field.Set(reflect.ValueOf(files[0]))
+	} else {
+		field.Set(reflect.ValueOf(files))
+	}
+
+	return nil
 }
 
 func getInTagLocAndName(fieldType reflect.StructField) (loc, name string) {
@@ -204,6 +347,39 @@ func getInTagLocAndName(fieldType reflect.StructField) (loc, name string) {
 	return
 }
 
+type keysGetter interface {
+	Get(string) (any, bool)
+}
+
+// bindKeyValue sets field to the value stored under key, the value is assigned or
+// converted directly, string values are bound like the other sources.
+func bindKeyValue(field reflect.Value, key string, src Source) (ok bool, err error) {
+	getter, isGetter := src.(keysGetter)
+	if !isGetter {
+		return false, nil
+	}
+
+	value, exists := getter.Get(key)
+	if !exists || value == nil {
+		return false, nil
+	}
+
+	val := reflect.ValueOf(value)
+	switch {
+	case val.Type().AssignableTo(field.Type()):
+		field.Set(val)
+	case val.Kind() == reflect.String:
+		if err := setValues(field, []string{val.String()}); err != nil {
+			return false, err
+		}
+	case val.Type().ConvertibleTo(field.Type()) && field.Kind() != reflect.String:
+		field.Set(val.Convert(field.Type()))
+	default:
+		return false, fmt.Errorf("easybind: ctx value %q of type %s can't bind to %s", key, val.Type(), field.Type())
+	}
+	return true, nil
+}
+
 type giner interface {
 	Param(string) string
 }
@@ -1,11 +1,18 @@
 package easybind
 
 import (
+	"bytes"
+	stdjson "encoding/json"
 	"fmt"
+	"math/big"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -47,3 +54,439 @@ func TestBind(t *testing.T) {
 	assert.Equal(t, true, args.OK)
 	fmt.Printf("===== %#v \n", args)
 }
+
+type uploadArgs struct {
+	Name   string                  `pos:"form:name"`
+	Avatar *multipart.FileHeader   `pos:"file:avatar"`
+	Photos []*multipart.FileHeader `pos:"file:photos"`
+}
+
+func TestBindMultipartFiles(t *testing.T) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "fox")
+	w, _ := mw.CreateFormFile("avatar", "avatar.png")
+	w.Write([]byte("avatar"))
+	for _, name := range []string{"a.png", "b.png"} {
+		w, _ = mw.CreateFormFile("photos", name)
+		w.Write([]byte(name))
+	}
+	mw.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "https://hello.world/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	args := uploadArgs{}
+	err := Bind(req, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, "fox", args.Name)
+	assert.Equal(t, "avatar.png", args.Avatar.Filename)
+	assert.Len(t, args.Photos, 2)
+	assert.Equal(t, "b.png", args.Photos[1].Filename)
+
+	type invalidArgs struct {
+		Avatar string `pos:"file:avatar"`
+	}
+	err = Bind(req, &invalidArgs{})
+	assert.NotNil(t, err)
+}
+
+type listFilter struct {
+	Status string `pos:"query:status"`
+	Owner  string `json:"owner"`
+}
+
+type listPage struct {
+	Size   int
+	Number int `json:"number"`
+}
+
+type listItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type listArgs struct {
+	Filter listFilter          `pos:"query:filter"`
+	Page   *listPage           `pos:"query:page"`
+	Labels map[string]string   `pos:"query:labels"`
+	Groups map[string][]int    `pos:"query:groups"`
+	Items  []listItem          `pos:"query:items"`
+	Extra  map[string]listPage `pos:"form:extra"`
+}
+
+func TestBindNested(t *testing.T) {
+	query := "filter[status]=x&filter[owner]=y&page.size=20&page[number]=2" +
+		"&labels[env]=prod&labels[app.name]=fox&groups[a]=1&groups[a]=2&groups[b]=3" +
+		"&items[1].id=2&items[1][name]=b&items[0].id=1"
+	form := url.Values{}
+	form.Set("extra[x].size", "5")
+
+	req, _ := http.NewRequest(http.MethodPost, "https://hello.world/users?"+query, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	args := listArgs{}
+	err := Bind(req, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, listFilter{Status: "x", Owner: "y"}, args.Filter)
+	assert.Equal(t, &listPage{Size: 20, Number: 2}, args.Page)
+	assert.Equal(t, map[string]string{"env": "prod", "app.name": "fox"}, args.Labels)
+	assert.Equal(t, map[string][]int{"a": {1, 2}, "b": {3}}, args.Groups)
+	assert.Equal(t, []listItem{{ID: 1}, {ID: 2, Name: "b"}}, args.Items)
+	assert.Equal(t, map[string]listPage{"x": {Size: 5}}, args.Extra)
+
+	req, _ = http.NewRequest(http.MethodGet, "https://hello.world/users?items[100000].id=1", nil)
+	err = Bind(req, &listArgs{})
+	assert.NotNil(t, err)
+
+	// the values of the same field are bound in the order of their sorted keys
+	for i := 0; i < 20; i++ {
+		req, _ = http.NewRequest(http.MethodGet, "https://hello.world/users?groups[a]=3&groups.a=1&groups.a=2", nil)
+		args = listArgs{}
+		assert.Nil(t, Bind(req, &args))
+		assert.Equal(t, map[string][]int{"a": {1, 2, 3}}, args.Groups)
+	}
+}
+
+type keys map[string]any
+
+func (k keys) Get(key string) (any, bool) {
+	v, ok := k[key]
+	return v, ok
+}
+
+type currentUser struct {
+	ID int
+}
+
+type defaultArgs struct {
+	Limit   int          `pos:"query:limit" default:"20"`
+	Offset  int          `pos:"query:offset" default:"5"`
+	Order   string       `pos:"header:X-Order" default:"asc"`
+	Session string       `pos:"cookie:session"`
+	Theme   string       `pos:"cookie:theme" default:"light"`
+	User    *currentUser `pos:"ctx:user"`
+	TraceID string       `pos:"ctx:trace_id"`
+	Role    string       `pos:"ctx:role" default:"guest"`
+	Age     int          `json:"age" default:"18"`
+}
+
+func TestBindDefaultCookieAndCtx(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://hello.world/users?offset=10", strings.NewReader(`{}`))
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+
+	user := &currentUser{ID: 1}
+	args := defaultArgs{}
+	err := Bind(req, &args, keys{"user": user, "trace_id": "t-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, 20, args.Limit)
+	assert.Equal(t, 10, args.Offset)
+	assert.Equal(t, "asc", args.Order)
+	assert.Equal(t, "abc", args.Session)
+	assert.Equal(t, "light", args.Theme)
+	assert.Equal(t, user, args.User)
+	assert.Equal(t, "t-1", args.TraceID)
+	assert.Equal(t, "guest", args.Role)
+	assert.Equal(t, 18, args.Age)
+
+	req, _ = http.NewRequest(http.MethodGet, "https://hello.world/users", strings.NewReader(`{"age": 30}`))
+	err = Bind(req, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, 30, args.Age)
+
+	err = Bind(req, &defaultArgs{}, keys{"user": 1})
+	assert.NotNil(t, err)
+}
+
+type level int
+
+func (l *level) UnmarshalParam(param string) error {
+	switch param {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %s", param)
+	}
+	return nil
+}
+
+type upper string
+
+func (u *upper) UnmarshalText(text []byte) error {
+	*u = upper(strings.ToUpper(string(text)))
+	return nil
+}
+
+type unmarshalerArgs struct {
+	Level    level          `pos:"query:level"`
+	Name     upper          `pos:"query:name"`
+	Big      *big.Int       `pos:"query:big"`
+	Timeout  time.Duration  `pos:"query:timeout"`
+	IP       net.IP         `pos:"query:ip"`
+	IPs      []net.IP       `pos:"query:ips"`
+	Callback url.URL        `pos:"query:callback"`
+	ID       stdjson.Number `pos:"header:X-ID"`
+}
+
+func TestBindUnmarshaler(t *testing.T) {
+	query := url.Values{}
+	query.Set("level", "high")
+	query.Set("name", "fox")
+	query.Set("big", "123456789012345678901234567890")
+	query.Set("timeout", "1m30s")
+	query.Set("ip", "10.0.0.1")
+	query.Add("ips", "::1")
+	query.Add("ips", "127.0.0.1")
+	query.Set("callback", "https://hello.world/callback?a=1")
+
+	req, _ := http.NewRequest(http.MethodGet, "https://hello.world/users?"+query.Encode(), nil)
+	req.Header.Set("X-ID", "9007199254740993")
+
+	args := unmarshalerArgs{}
+	err := Bind(req, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, level(2), args.Level)
+	assert.Equal(t, upper("FOX"), args.Name)
+	assert.Equal(t, "123456789012345678901234567890", args.Big.String())
+	assert.Equal(t, 90*time.Second, args.Timeout)
+	assert.Equal(t, "10.0.0.1", args.IP.String())
+	assert.Len(t, args.IPs, 2)
+	assert.Equal(t, "127.0.0.1", args.IPs[1].String())
+	assert.Equal(t, "hello.world", args.Callback.Host)
+	assert.Equal(t, stdjson.Number("9007199254740993"), args.ID)
+
+	// the invalid values are rejected instead of bound to zero
+	for _, invalid := range []string{"level=none", "timeout=soon", "ip=10.0.0", "ips=::1&ips=localhost", "callback=" + url.QueryEscape("http://[::1")} {
+		req, _ = http.NewRequest(http.MethodGet, "https://hello.world/users?"+invalid, nil)
+		err = Bind(req, &unmarshalerArgs{})
+		assert.Error(t, err, invalid)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "https://hello.world/users?level=none", nil)
+	err = Bind(req, &unmarshalerArgs{})
+	assert.EqualError(t, err, `easybind: can't bind "none" to easybind.level: unknown level none`)
+
+	req, _ = http.NewRequest(http.MethodGet, "https://hello.world/users", nil)
+	req.Header.Set("X-ID", "nine")
+	assert.Error(t, Bind(req, &unmarshalerArgs{}))
+
+	// the empty values are still bound to zero
+	req, _ = http.NewRequest(http.MethodGet, "https://hello.world/users?timeout=&ip=", nil)
+	assert.NoError(t, Bind(req, &unmarshalerArgs{}))
+}
+
+type strictItem struct {
+	ID int `json:"id"`
+}
+
+type strictArgs struct {
+	Email string       `json:"email"`
+	Items []strictItem `json:"items"`
+	Meta  interface{}  `json:"meta"`
+}
+
+type strictStructArgs struct {
+	StrictJSON
+	Email string `json:"email"`
+}
+
+func TestBindStrictJSON(t *testing.T) {
+	newRequest := func(body string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "https://hello.world/users", strings.NewReader(body))
+		return req
+	}
+
+	args := strictArgs{}
+	err := Bind(newRequest(`{"emial": "fox@hello.world"}`), &args)
+	assert.Nil(t, err)
+
+	err = Bind(newRequest(`{"emial": "fox@hello.world"}`), &args, Options{DisallowUnknownFields: true})
+	assert.Equal(t, &UnknownFieldError{Path: "emial"}, err)
+
+	err = Bind(newRequest(`{"email": "fox@hello.world", "items": [{"id": 1}, {"ID": 2, "name": "x"}]}`), &args, Options{DisallowUnknownFields: true})
+	assert.Equal(t, &UnknownFieldError{Path: "items[1].name"}, err)
+
+	err = Bind(newRequest(`{"emial": "fox@hello.world"}`), &strictStructArgs{})
+	assert.Equal(t, &UnknownFieldError{Path: "emial"}, err)
+
+	args = strictArgs{}
+	err = Bind(newRequest(`{"meta": {"id": 9007199254740993}}`), &args, Options{UseNumber: true})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"id": stdjson.Number("9007199254740993")}, args.Meta)
+}
+
+type timeArgs struct {
+	Day      time.Time   `pos:"path:day" time_format:"2006-01-02" time_location:"UTC"`
+	Since    *time.Time  `pos:"query:since" time_unit:"ms"`
+	Until    time.Time   `pos:"header:X-Until" time_format:"2006/01/02 15:04" time_location:"Asia/Shanghai"`
+	Dates    []time.Time `pos:"form:dates" time_format:"20060102" time_location:"UTC"`
+	Datetime time.Time   `pos:"query:datetime"`
+	Default  time.Time   `pos:"query:default" time_format:"2006-01-02" default:"2022-01-01"`
+}
+
+type params map[string]string
+
+func (p params) ByName(name string) string {
+	return p[name]
+}
+
+func TestBindTime(t *testing.T) {
+	form := url.Values{"dates": []string{"20220101", "20220102"}}
+	req, _ := http.NewRequest(http.MethodPost, "https://hello.world/days?since=1640995200123&datetime=2022-01-01+10:30", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Until", "2022/01/02 08:00")
+
+	args := timeArgs{}
+	err := Bind(req, &args, params{"day": "2022-01-01"})
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), args.Day)
+	assert.Equal(t, int64(1640995200123), args.Since.UnixMilli())
+	assert.Equal(t, time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), args.Until.UTC())
+	assert.Equal(t, []time.Time{time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)}, args.Dates)
+	assert.Equal(t, time.Date(2022, 1, 1, 10, 30, 0, 0, time.Local), args.Datetime)
+	assert.Equal(t, "2022-01-01", args.Default.Format("2006-01-02"))
+
+	type invalidArgs struct {
+		Day time.Time `pos:"query:day" time_location:"Nowhere/City"`
+	}
+	err = Bind(req, &invalidArgs{})
+	assert.NotNil(t, err)
+
+	// invalid values aren't bound as the zero time
+	for query, args := range map[string]interface{}{
+		"day=garbage": &struct {
+			Day time.Time `pos:"query:day" time_format:"2006-01-02"`
+		}{},
+		"day=2022-13-01": &struct {
+			Day *time.Time `pos:"query:day" time_format:"2006-01-02"`
+		}{},
+		"since=yesterday": &struct {
+			Since time.Time `pos:"query:since" time_unit:"s"`
+		}{},
+		"day=garbage&x=1": &struct {
+			Days []time.Time `pos:"query:day" time_location:"UTC"`
+		}{},
+	} {
+		req, _ = http.NewRequest(http.MethodGet, "https://hello.world/days?"+query, nil)
+		assert.NotNil(t, Bind(req, args), query)
+	}
+}
+
+type styleArgs struct {
+	Repeated []int    `pos:"query:repeated"`
+	Brackets []int    `pos:"query:brackets"`
+	Comma    []int    `pos:"query:comma,style=form,explode=false"`
+	Space    []string `pos:"query:space,style=spaceDelimited"`
+	Pipe     []int    `pos:"query:pipe,style=pipeDelimited"`
+	Header   []string `pos:"header:X-Tags"`
+	Path     []int    `pos:"path:ids"`
+	Filter   listPage `pos:"query:filter,style=deepObject"`
+	Default  []int    `pos:"query:default,required,explode=false" default:"1,2"`
+}
+
+func TestBindStyle(t *testing.T) {
+	query := "repeated=1&repeated=2&brackets[]=3&brackets[]=4&comma=5,6&space=a%20b&pipe=7|8&filter[size]=10"
+	req, _ := http.NewRequest(http.MethodGet, "https://hello.world/users?"+query, nil)
+	req.Header.Add("X-Tags", "a, b")
+	req.Header.Add("X-Tags", "c")
+
+	args := styleArgs{}
+	err := Bind(req, &args, params{"ids": "9,10"})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, args.Repeated)
+	assert.Equal(t, []int{3, 4}, args.Brackets)
+	assert.Equal(t, []int{5, 6}, args.Comma)
+	assert.Equal(t, []string{"a", "b"}, args.Space)
+	assert.Equal(t, []int{7, 8}, args.Pipe)
+	assert.Equal(t, []string{"a", "b", "c"}, args.Header)
+	assert.Equal(t, []int{9, 10}, args.Path)
+	assert.Equal(t, 10, args.Filter.Size)
+	assert.Equal(t, []int{1, 2}, args.Default)
+
+	ps, err := Parameters(&args)
+	assert.Nil(t, err)
+	assert.Len(t, ps, 9)
+	assert.Equal(t, Parameter{Name: "repeated", In: "query", Style: StyleForm, Explode: true, Type: reflect.TypeOf([]int{})}, ps[0])
+	assert.Equal(t, Parameter{Name: "pipe", In: "query", Style: StylePipeDelimited, Type: reflect.TypeOf([]int{})}, ps[4])
+	assert.Equal(t, Parameter{Name: "ids", In: "path", Style: StyleSimple, Required: true, Type: reflect.TypeOf([]int{})}, ps[6])
+	assert.Equal(t, Parameter{Name: "default", In: "query", Style: StyleForm, Required: true, Default: "1,2", Type: reflect.TypeOf([]int{})}, ps[8])
+
+	type invalidArgs struct {
+		IDs []int `pos:"query:ids,style=matrix"`
+	}
+	err = Bind(req, &invalidArgs{})
+	assert.NotNil(t, err)
+
+	type deepObjectArgs struct {
+		IDs []int `pos:"query:ids,style=deepObject"`
+	}
+	err = Bind(req, &deepObjectArgs{})
+	assert.NotNil(t, err)
+}
+
+func TestBindHeaderSlice(t *testing.T) {
+	type headerArgs struct {
+		Tags []string `pos:"header:X-Tags"`
+		IDs  []int    `pos:"header:X-IDs"`
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://hello.world/users", nil)
+	req.Header.Set("X-Tags", "a, b ,c")
+	req.Header.Set("X-IDs", "1, 2")
+
+	args := headerArgs{}
+	assert.Nil(t, Bind(req, &args))
+	assert.Equal(t, []string{"a", "b", "c"}, args.Tags)
+	assert.Equal(t, []int{1, 2}, args.IDs)
+}
+
+type messageArgs struct {
+	ID      int                   `pos:"path:id"`
+	Limit   int                   `pos:"query:limit" default:"20"`
+	Tags    []string              `pos:"query:tags,explode=false"`
+	Filter  map[string]string     `pos:"query:filter"`
+	Token   string                `pos:"header:X-Token"`
+	Name    string                `pos:"form:name"`
+	Session string                `pos:"cookie:session"`
+	User    *currentUser          `pos:"ctx:user"`
+	Avatar  *multipart.FileHeader `pos:"file:avatar"`
+	Age     int                   `json:"age"`
+}
+
+func TestBindFrom(t *testing.T) {
+	user := &currentUser{ID: 1}
+	src := &MapSource{
+		PathParams:  map[string]string{"id": "10"},
+		QueryValues: url.Values{"tags": []string{"a,b"}, "filter[status]": []string{"active"}},
+		Headers:     http.Header{"X-Token": []string{"secret"}},
+		FormValues:  url.Values{"name": []string{"fox"}},
+		Cookies:     map[string]string{"session": "abc"},
+		Values:      map[string]any{"user": user},
+		RawBody:     []byte(`{"age": 20}`),
+	}
+
+	args := messageArgs{}
+	err := BindFrom(src, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, messageArgs{
+		ID:      10,
+		Limit:   20,
+		Tags:    []string{"a", "b"},
+		Filter:  map[string]string{"status": "active"},
+		Token:   "secret",
+		Name:    "fox",
+		Session: "abc",
+		User:    user,
+		Age:     20,
+	}, args)
+
+	args = messageArgs{}
+	err = BindFrom(&MapSource{}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, args.Limit)
+
+	err = BindFrom(&MapSource{RawBody: []byte(`{"agee": 20}`)}, &args, Options{DisallowUnknownFields: true})
+	assert.Equal(t, &UnknownFieldError{Path: "agee"}, err)
+}
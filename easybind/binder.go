@@ -1,12 +1,28 @@
 package easybind
 
 import (
+	"encoding"
+	stdjson "encoding/json"
+	"fmt"
+	"net"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// BindUnmarshaler is the interface implemented by types that can unmarshal a string value
+// from path, query, header, form and so on. It takes precedence over encoding.TextUnmarshaler.
+type BindUnmarshaler interface {
+	UnmarshalParam(param string) error
+}
+
+var (
+	bindUnmarshalerType = reflect.TypeOf((*BindUnmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
 func stringBinder(val string, typ reflect.Type) reflect.Value {
 	return reflect.ValueOf(val)
 }
@@ -62,6 +78,14 @@ func boolBinder(val string, typ reflect.Type) reflect.Value {
 }
 
 func timeBinder(val string, typ reflect.Type) reflect.Value {
+	if t, ok := parseTime(val, time.Local); ok {
+		return reflect.ValueOf(t)
+	}
+	return reflect.Zero(typ)
+}
+
+// parseTime parses val with TimeFormats, the formats without zone are parsed in loc.
+func parseTime(val string, loc *time.Location) (time.Time, bool) {
 	for _, f := range TimeFormats {
 		if f == "" {
 			continue
@@ -69,20 +93,103 @@ func timeBinder(val string, typ reflect.Type) reflect.Value {
 
 		if strings.Contains(f, "07") || strings.Contains(f, "MST") {
 			if r, err := time.Parse(f, val); err == nil {
-				return reflect.ValueOf(r)
+				return r, true
 			}
 		} else {
-			if r, err := time.ParseInLocation(f, val, time.Local); err == nil {
-				return reflect.ValueOf(r)
+			if r, err := time.ParseInLocation(f, val, loc); err == nil {
+				return r, true
 			}
 		}
 	}
 
 	if unixInt, err := strconv.ParseInt(val, 10, 64); err == nil {
-		return reflect.ValueOf(time.Unix(unixInt, 0))
+		return time.Unix(unixInt, 0).In(loc), true
 	}
 
-	return reflect.Zero(typ)
+	return time.Time{}, false
+}
+
+func durationParser(val string, typ reflect.Type) (reflect.Value, error) {
+	if d, err := time.ParseDuration(val); err == nil {
+		return reflect.ValueOf(d), nil
+	}
+
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return reflect.Zero(typ), errInvalidValue(val, typ, nil)
+	}
+	return reflect.ValueOf(time.Duration(n)), nil
+}
+
+func ipParser(val string, typ reflect.Type) (reflect.Value, error) {
+	ip := net.ParseIP(strings.TrimSpace(val))
+	if ip == nil {
+		return reflect.Zero(typ), errInvalidValue(val, typ, nil)
+	}
+	return reflect.ValueOf(ip), nil
+}
+
+func urlParser(val string, typ reflect.Type) (reflect.Value, error) {
+	u, err := url.Parse(val)
+	if err != nil {
+		return reflect.Zero(typ), errInvalidValue(val, typ, err)
+	}
+	return reflect.ValueOf(*u), nil
+}
+
+func numberParser(val string, typ reflect.Type) (reflect.Value, error) {
+	if _, err := strconv.ParseFloat(val, 64); err != nil {
+		return reflect.Zero(typ), errInvalidValue(val, typ, nil)
+	}
+	return reflect.ValueOf(stdjson.Number(val)), nil
+}
+
+// isUnmarshaler reports whether the pointer of typ implements BindUnmarshaler or encoding.TextUnmarshaler.
+func isUnmarshaler(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		return false
+	}
+	ptr := reflect.PointerTo(typ)
+	return ptr.Implements(bindUnmarshalerType) || ptr.Implements(textUnmarshalerType)
+}
+
+// unmarshalerParser binds val with UnmarshalParam or UnmarshalText, so the types can
+// reject invalid values, e.g. an enum type.
+func unmarshalerParser(val string, typ reflect.Type) (reflect.Value, error) {
+	var (
+		pValue = reflect.New(typ)
+		err    error
+	)
+
+	switch u := pValue.Interface().(type) {
+	case BindUnmarshaler:
+		err = u.UnmarshalParam(val)
+	case encoding.TextUnmarshaler:
+		err = u.UnmarshalText([]byte(val))
+	}
+
+	if err != nil {
+		return reflect.Zero(typ), errInvalidValue(val, typ, err)
+	}
+	return pValue.Elem(), nil
+}
+
+// errInvalidValue returns the error of val which can't bind to typ, err is the cause if any.
+func errInvalidValue(val string, typ reflect.Type, err error) error {
+	if err != nil {
+		return fmt.Errorf("easybind: can't bind %q to %s: %w", val, typ, err)
+	}
+	return fmt.Errorf("easybind: can't bind %q to %s", val, typ)
+}
+
+// hasTypeBinder reports whether typ is bound as a whole value rather than by its kind,
+// such as time.Time, net.IP and types implementing BindUnmarshaler.
+func hasTypeBinder(typ reflect.Type) bool {
+	_, ok := TypeBinders[typ]
+	if !ok {
+		_, ok = typeParsers[typ]
+	}
+	return ok || isUnmarshaler(typ)
 }
 
 func pointerBinder(val string, typ reflect.Type) reflect.Value {
@@ -96,42 +203,92 @@ func pointerBinder(val string, typ reflect.Type) reflect.Value {
 	return p.Addr()
 }
 
-func sliceBinder(vals []string, typ reflect.Type) reflect.Value {
+func sliceBinder(vals []string, typ reflect.Type) (reflect.Value, error) {
 	slices := reflect.MakeSlice(typ, 0, len(vals))
 	for i := 0; i < len(vals); i++ {
-		val := BindValue(vals[i], typ.Elem())
+		val, err := bindValue(vals[i], typ.Elem())
+		if err != nil {
+			return slices, err
+		}
 		slices = reflect.Append(slices, val.Convert(typ.Elem()))
 	}
 
-	return slices
+	return slices, nil
 }
 
 const (
 	// DefaultDateFormat day
 	DefaultDateFormat = "2006-01-02"
 	// DefaultDatetimeFormat minute
-	DefaultDatetimeFormat = "2006-01-02 15:0"
+	DefaultDatetimeFormat = "2006-01-02 15:04"
 	// DefaultDatetimeFormatSecond second
 	DefaultDatetimeFormatSecond = "2006-01-02 15:04:05"
 )
 
-// BindValue string to specified type
+// BindValue string to specified type, the binders are looked up in order:
+// TypeBinders, the built-in types, BindUnmarshaler, encoding.TextUnmarshaler, KindBinders.
+// The invalid values of the built-in types and the unmarshalers are bound to zero,
+// Bind returns their errors instead.
 func BindValue(val string, typ reflect.Type) reflect.Value {
-	binder, ok := TypeBinders[typ]
-	if !ok {
-		binder, ok = KindBinders[typ.Kind()]
-		if !ok {
-			// WARN.Println("no binder for type:", typ)
-			// TODO slice | struct
-			return reflect.Zero(typ)
+	v, err := bindValue(val, typ)
+	if err != nil {
+		return reflect.Zero(typ)
+	}
+	return v
+}
+
+// bindValue is like BindValue, but returns the errors of the invalid values.
+func bindValue(val string, typ reflect.Type) (reflect.Value, error) {
+	if binder, ok := TypeBinders[typ]; ok {
+		return binder(val, typ), nil
+	}
+
+	if parser, ok := typeParsers[typ]; ok {
+		if len(val) == 0 {
+			return reflect.Zero(typ), nil
+		}
+		return parser(val, typ)
+	}
+
+	if isUnmarshaler(typ) {
+		return unmarshalerParser(val, typ)
+	}
+
+	if typ.Kind() == reflect.Ptr {
+		if len(val) == 0 {
+			return reflect.Zero(typ), nil
 		}
+		v, err := bindValue(val, typ.Elem())
+		if err != nil {
+			return reflect.Zero(typ), err
+		}
+		p := reflect.New(v.Type()).Elem()
+		p.Set(v)
+		return p.Addr(), nil
 	}
 
-	return binder(val, typ)
+	binder, ok := KindBinders[typ.Kind()]
+	if !ok {
+		// WARN.Println("no binder for type:", typ)
+		// TODO slice | struct
+		return reflect.Zero(typ), nil
+	}
+	return binder(val, typ), nil
 }
 
 type binder func(string, reflect.Type) reflect.Value
 
+// parser is like binder, but returns the error of an invalid value, so Bind can reject it.
+type parser func(string, reflect.Type) (reflect.Value, error)
+
+// typeParsers parse the built-in types besides time.Time.
+var typeParsers = map[reflect.Type]parser{
+	reflect.TypeOf(time.Duration(0)):   durationParser,
+	reflect.TypeOf(net.IP{}):           ipParser,
+	reflect.TypeOf(url.URL{}):          urlParser,
+	reflect.TypeOf(stdjson.Number("")): numberParser,
+}
+
 var (
 	// TimeFormats supported time formats, also support unix time and time.RFC3339.
 	TimeFormats []string
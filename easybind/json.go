@@ -0,0 +1,213 @@
+package easybind
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Options configures how Bind decodes the request, pass it to Bind along with pathQueryier.
+type Options struct {
+	// DisallowUnknownFields rejects JSON bodies with fields not in the struct,
+	// the error is an *UnknownFieldError naming the JSON path.
+	DisallowUnknownFields bool
+
+	// UseNumber decodes JSON numbers into interface{} values as json.Number
+	// instead of float64, so integers over 2^53 keep their precision.
+	UseNumber bool
+
+	// MaxMultipartMemory is the maxMemory argument passed to http.Request.ParseMultipartForm,
+	// file parts beyond this limit are stored in temporary files on disk. 32 MB if it's zero.
+	MaxMultipartMemory int64
+}
+
+// StrictJSON can be embedded into an arguments struct to reject unknown JSON fields
+// for this struct only, whatever the Options are.
+/*
+type CreateUserArgs struct {
+	easybind.StrictJSON
+	Email string `json:"email"`
+}
+*/
+type StrictJSON struct{}
+
+func (StrictJSON) disallowUnknownFields() {}
+
+type strictJSONer interface {
+	disallowUnknownFields()
+}
+
+// UnknownFieldError is returned when the JSON body has a field not in the struct.
+type UnknownFieldError struct {
+	// Path of the field, e.g. items[0].emial
+	Path string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("json: unknown field %q", e.Path)
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*stdjson.Unmarshaler)(nil)).Elem()
+
+func getOptions(pathQueryier ...interface{}) Options {
+	for _, q := range pathQueryier {
+		switch opts := q.(type) {
+		case Options:
+			return opts
+		case *Options:
+			return *opts
+		}
+	}
+	return Options{}
+}
+
+// decodeJSON decodes the JSON body into params.
+func decodeJSON(body io.Reader, params interface{}, opts Options) error {
+	if _, ok := params.(strictJSONer); ok {
+		opts.DisallowUnknownFields = true
+	}
+
+	if !opts.DisallowUnknownFields {
+		decoder := json.NewDecoder(body)
+		if opts.UseNumber {
+			decoder.UseNumber()
+		}
+		return decoder.Decode(params)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if opts.UseNumber {
+		decoder.UseNumber()
+	}
+
+	if err = decoder.Decode(params); err != nil && strings.Contains(err.Error(), "unknown field") {
+		if path := unknownFieldPath(data, reflect.TypeOf(params)); path != "" {
+			return &UnknownFieldError{Path: path}
+		}
+	}
+	return err
+}
+
+// unknownFieldPath returns the path of the first field in data that typ doesn't have.
+func unknownFieldPath(data []byte, typ reflect.Type) string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return ""
+	}
+	return findUnknownField(v, typ, "")
+}
+
+func findUnknownField(v interface{}, typ reflect.Type, path string) string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if reflect.PointerTo(typ).Implements(jsonUnmarshalerType) {
+		return ""
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		switch typ.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(typ)
+			for _, key := range keys {
+				fieldType, ok := lookupJSONField(fields, key)
+				if !ok {
+					return joinJSONPath(path, key)
+				}
+				if p := findUnknownField(val[key], fieldType, joinJSONPath(path, key)); p != "" {
+					return p
+				}
+			}
+		case reflect.Map:
+			for _, key := range keys {
+				if p := findUnknownField(val[key], typ.Elem(), joinJSONPath(path, key)); p != "" {
+					return p
+				}
+			}
+		}
+	case []interface{}:
+		if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+			for i, elem := range val {
+				if p := findUnknownField(elem, typ.Elem(), fmt.Sprintf("%s[%d]", path, i)); p != "" {
+					return p
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// jsonFields returns the JSON names of the struct fields, fields of embedded structs included.
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, tagSep)[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, v := range jsonFields(embedded) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// lookupJSONField finds the field by exact name first, then case-insensitively as encoding/json does.
+func lookupJSONField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if typ, ok := fields[key]; ok {
+		return typ, true
+	}
+	for name, typ := range fields {
+		if strings.EqualFold(name, key) {
+			return typ, true
+		}
+	}
+	return nil, false
+}
+
+func joinJSONPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
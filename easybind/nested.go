@@ -0,0 +1,256 @@
+package easybind
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxSliceIndex limits indexed keys like items[1000].id, a larger index would
+// allocate a huge slice from a tiny request.
+const maxSliceIndex = 1000
+
+// nestedValue is a value of url.Values whose key is split into path segments,
+// filter[status] and filter.status are both ["filter", "status"].
+type nestedValue struct {
+	path   []string
+	values []string
+}
+
+// isNested reports whether typ is bound from nested keys, such as struct, map,
+// and slice of them.
+func isNested(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if hasTypeBinder(typ) {
+		return false
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct, reflect.Map:
+		return true
+	case reflect.Slice:
+		return isNested(typ.Elem())
+	}
+	return false
+}
+
+// splitKey splits a key with bracket and dot notation into path segments,
+// items[0].id returns ["items", "0", "id"].
+func splitKey(key string) (parts []string) {
+	for len(key) > 0 {
+		switch key[0] {
+		case '.':
+			key = key[1:]
+		case '[':
+			end := strings.IndexByte(key, ']')
+			if end < 0 {
+				return append(parts, key[1:])
+			}
+			parts = append(parts, key[1:end])
+			key = key[end+1:]
+		default:
+			end := strings.IndexAny(key, ".[")
+			if end < 0 {
+				end = len(key)
+			}
+			parts = append(parts, key[:end])
+			key = key[end:]
+		}
+	}
+	return
+}
+
+// bindNested binds all the values whose key starts with name to field, the keys are
+// sorted so the values of the same field, e.g. filter[status] and filter.status, are
+// bound in a deterministic order.
+func bindNested(field reflect.Value, name string, values url.Values) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries []nestedValue
+	for _, key := range keys {
+		parts := splitKey(key)
+		if len(parts) == 0 || parts[0] != name {
+			continue
+		}
+		entries = append(entries, nestedValue{path: parts[1:], values: values[key]})
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return setNested(field, entries)
+}
+
+func setNested(v reflect.Value, entries []nestedValue) error {
+	if !isNested(v.Type()) {
+		var values []string
+		for _, entry := range entries {
+			if len(entry.path) == 0 {
+				values = append(values, entry.values...)
+			}
+		}
+		return setValues(v, values)
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setNested(v.Elem(), entries)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return setNestedStruct(v, entries)
+	case reflect.Map:
+		return setNestedMap(v, entries)
+	case reflect.Slice:
+		return setNestedSlice(v, entries)
+	}
+	return nil
+}
+
+// groupEntries groups entries by the first path segment, the keys are in the order of
+// their first entry, entries are sorted by bindNested.
+func groupEntries(entries []nestedValue) (keys []string, groups map[string][]nestedValue) {
+	groups = make(map[string][]nestedValue)
+	for _, entry := range entries {
+		if len(entry.path) == 0 {
+			continue
+		}
+		key := entry.path[0]
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], nestedValue{path: entry.path[1:], values: entry.values})
+	}
+	return
+}
+
+func setNestedStruct(v reflect.Value, entries []nestedValue) error {
+	var (
+		typ          = v.Type()
+		keys, groups = groupEntries(entries)
+	)
+
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		if fieldType.PkgPath != "" && !fieldType.Anonymous {
+			continue
+		}
+
+		if fieldType.Anonymous && fieldType.Type.Kind() == reflect.Struct {
+			if err := setNestedStruct(v.Field(i), entries); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := nestedFieldName(fieldType)
+		sub, ok := groups[name]
+		if !ok {
+			for _, key := range keys {
+				if strings.EqualFold(key, name) {
+					sub, ok = groups[key], true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		if err := setNested(v.Field(i), sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nestedFieldName returns the key of a nested struct field, name of `pos` tag first,
+// then name of `json` tag, otherwise the field name.
+func nestedFieldName(fieldType reflect.StructField) string {
+	if fieldType.Tag.Get(tagNameIn) != "" {
+		if _, name := getInTagLocAndName(fieldType); name != "" {
+			return name
+		}
+	}
+
+	if name := strings.Split(fieldType.Tag.Get("json"), tagSep)[0]; name != "" && name != "-" {
+		return name
+	}
+
+	return fieldType.Name
+}
+
+func setNestedMap(v reflect.Value, entries []nestedValue) error {
+	typ := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(typ))
+	}
+
+	keys, groups := groupEntries(entries)
+	for _, key := range keys {
+		keyVal, err := bindValue(key, typ.Key())
+		if err != nil {
+			return err
+		}
+		if !keyVal.Type().ConvertibleTo(typ.Key()) {
+			return fmt.Errorf("easybind: can't bind map key %q to %s", key, typ.Key())
+		}
+		keyVal = keyVal.Convert(typ.Key())
+
+		elem := reflect.New(typ.Elem()).Elem()
+		if old := v.MapIndex(keyVal); old.IsValid() {
+			elem.Set(old)
+		}
+		if err := setNested(elem, groups[key]); err != nil {
+			return err
+		}
+		v.SetMapIndex(keyVal, elem)
+	}
+	return nil
+}
+
+func setNestedSlice(v reflect.Value, entries []nestedValue) error {
+	var (
+		keys, groups = groupEntries(entries)
+		indexes      = make([]int, len(keys))
+		length       = v.Len()
+	)
+
+	for i, key := range keys {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index > maxSliceIndex {
+			return fmt.Errorf("easybind: invalid slice index %q", key)
+		}
+		indexes[i] = index
+		if index >= length {
+			length = index + 1
+		}
+	}
+
+	if length > v.Len() {
+		slice := reflect.MakeSlice(v.Type(), length, length)
+		reflect.Copy(slice, v)
+		v.Set(slice)
+	}
+
+	for i, key := range keys {
+		if err := setNested(v.Index(indexes[i]), groups[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
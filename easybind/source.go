@@ -0,0 +1,226 @@
+package easybind
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Source provides the values of the `pos` tag locations, so the same struct tags
+// can bind an http.Request, a message-queue payload or CLI flags.
+//
+// A Source may also implement `Get(string) (any, bool)` for the ctx location and
+// `File(string) ([]*multipart.FileHeader, error)` for the file location.
+type Source interface {
+	// Path returns the value of the path variable name, "" if there is none.
+	Path(name string) string
+	// Query returns the query values.
+	Query() url.Values
+	// Header returns the values of the header name.
+	Header(name string) []string
+	// Form returns the form values.
+	Form() (url.Values, error)
+	// Cookie returns the value of the cookie name.
+	Cookie(name string) (string, bool)
+	// Body returns the JSON body, nil if there is none.
+	Body() (io.Reader, error)
+}
+
+type fileGetter interface {
+	File(string) ([]*multipart.FileHeader, error)
+}
+
+// bodyBuffer reads the request body once and returns it on every call, e.g. fox.Context.
+type bodyBuffer interface {
+	Body() ([]byte, error)
+}
+
+var (
+	_ Source     = &requestSource{}
+	_ keysGetter = &requestSource{}
+	_ fileGetter = &requestSource{}
+	_ Source     = &MapSource{}
+	_ keysGetter = &MapSource{}
+)
+
+// requestSource is the Source of an http.Request, the form and query are parsed only once.
+type requestSource struct {
+	req          *http.Request
+	pathQueryier []interface{}
+	maxMemory    int64
+
+	queryOnce sync.Once
+	query     url.Values
+
+	formOnce sync.Once
+	formErr  error
+}
+
+// NewRequestSource returns the Source of req, pathQueryier get variables from path and
+// values stored by middleware, as the pathQueryier of Bind. The multipart form is parsed
+// with the MaxMultipartMemory of the Options in pathQueryier.
+func NewRequestSource(req *http.Request, pathQueryier ...interface{}) Source {
+	maxMemory := getOptions(pathQueryier...).MaxMultipartMemory
+	if maxMemory <= 0 {
+		maxMemory = defaultMultipartMemory
+	}
+	return &requestSource{req: req, pathQueryier: pathQueryier, maxMemory: maxMemory}
+}
+
+func (s *requestSource) Path(name string) string {
+	return getValueFromPath(name, s.pathQueryier...)
+}
+
+func (s *requestSource) Query() url.Values {
+	s.queryOnce.Do(func() {
+		s.query = s.req.URL.Query()
+	})
+	return s.query
+}
+
+func (s *requestSource) Header(name string) []string {
+	return s.req.Header.Values(name)
+}
+
+// Form parses the request form only once, multipart bodies are parsed with the
+// MaxMultipartMemory of the Options.
+func (s *requestSource) Form() (url.Values, error) {
+	s.formOnce.Do(func() {
+		if isMultipart(s.req.Header.Get("Content-Type")) {
+			s.formErr = s.req.ParseMultipartForm(s.maxMemory)
+			return
+		}
+		s.formErr = s.req.ParseForm()
+	})
+	return s.req.PostForm, s.formErr
+}
+
+func (s *requestSource) Cookie(name string) (string, bool) {
+	cookie, err := s.req.Cookie(name)
+	if err != nil {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// Body returns the body buffered by the bodyBuffer of pathQueryier if any, so the body can be
+// bound more than once, otherwise the request body. An empty buffered body is nil.
+func (s *requestSource) Body() (io.Reader, error) {
+	for _, q := range s.pathQueryier {
+		if buffer, ok := q.(bodyBuffer); ok {
+			body, err := buffer.Body()
+			if err != nil || len(body) == 0 {
+				return nil, err
+			}
+			return bytes.NewReader(body), nil
+		}
+	}
+
+	if s.req.Body == nil {
+		return nil, nil
+	}
+	return s.req.Body, nil
+}
+
+func (s *requestSource) File(name string) ([]*multipart.FileHeader, error) {
+	if _, err := s.Form(); err != nil {
+		return nil, err
+	}
+
+	if s.req.MultipartForm == nil {
+		return nil, nil
+	}
+	return s.req.MultipartForm.File[name], nil
+}
+
+func (s *requestSource) Get(key string) (any, bool) {
+	for _, q := range s.pathQueryier {
+		if getter, ok := q.(keysGetter); ok {
+			return getter.Get(key)
+		}
+	}
+	return nil, false
+}
+
+// isMultipart reports whether contentType is multipart/form-data.
+func isMultipart(contentType string) bool {
+	return mediaType(contentType) == "multipart/form-data"
+}
+
+// isForm reports whether contentType is a form, its body is bound by the form and file
+// locations, not decoded as JSON.
+func isForm(contentType string) bool {
+	return isMultipart(contentType) || mediaType(contentType) == "application/x-www-form-urlencoded"
+}
+
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// MapSource is a Source backed by maps, for the callers without an http.Request.
+/*
+src := &easybind.MapSource{
+	QueryValues: url.Values{"limit": []string{"20"}},
+	RawBody:     message.Body,
+}
+err := easybind.BindFrom(src, &args)
+*/
+type MapSource struct {
+	PathParams  map[string]string
+	QueryValues url.Values
+	Headers     http.Header
+	FormValues  url.Values
+	Cookies     map[string]string
+	// Values are bound by the ctx location.
+	Values map[string]any
+	// RawBody is the JSON body.
+	RawBody []byte
+}
+
+// Path returns the value of PathParams.
+func (s *MapSource) Path(name string) string {
+	return s.PathParams[name]
+}
+
+// Query returns QueryValues.
+func (s *MapSource) Query() url.Values {
+	return s.QueryValues
+}
+
+// Header returns the values of Headers, name is canonicalized.
+func (s *MapSource) Header(name string) []string {
+	return s.Headers.Values(name)
+}
+
+// Form returns FormValues.
+func (s *MapSource) Form() (url.Values, error) {
+	return s.FormValues, nil
+}
+
+// Cookie returns the value of Cookies.
+func (s *MapSource) Cookie(name string) (string, bool) {
+	value, ok := s.Cookies[name]
+	return value, ok
+}
+
+// Body returns the reader of RawBody, nil if it's empty.
+func (s *MapSource) Body() (io.Reader, error) {
+	if len(s.RawBody) == 0 {
+		return nil, nil
+	}
+	return bytes.NewReader(s.RawBody), nil
+}
+
+// Get returns the value of Values.
+func (s *MapSource) Get(key string) (any, bool) {
+	value, ok := s.Values[key]
+	return value, ok
+}
@@ -0,0 +1,201 @@
+package easybind
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// OpenAPI parameter styles, specified by the `pos` tag option style=,
+// see https://spec.openapis.org/oas/v3.0.3#style-values
+const (
+	// StyleForm ids=1&ids=2, or ids=1,2 with explode=false. Default of query, form and cookie.
+	StyleForm = "form"
+	// StyleSimple ids=1,2. Default of path and header, so a []string header field binds
+	// "X-Tags: a, b" as []string{"a", "b"}, the values are trimmed.
+	StyleSimple = "simple"
+	// StyleSpaceDelimited ids=1%202.
+	StyleSpaceDelimited = "spaceDelimited"
+	// StylePipeDelimited ids=1|2.
+	StylePipeDelimited = "pipeDelimited"
+	// StyleDeepObject filter[status]=x, the style of nested structs and maps, it's rejected
+	// on the other fields.
+	StyleDeepObject = "deepObject"
+)
+
+// tagOptions are the options following the location and name of the `pos` tag,
+// e.g. `pos:"query:ids,required,style=pipeDelimited,explode=false"`.
+type tagOptions struct {
+	required bool
+	style    string
+	explode  bool
+}
+
+// getInTagOptions returns the options of `pos` tag, the OpenAPI defaults of the location are filled in.
+func getInTagOptions(fieldType reflect.StructField) (opts tagOptions, err error) {
+	loc, _ := getInTagLocAndName(fieldType)
+
+	var explode string
+	for _, opt := range strings.Split(fieldType.Tag.Get(tagNameIn), tagSep)[1:] {
+		key, value := opt, ""
+		if i := strings.IndexByte(opt, '='); i >= 0 {
+			key, value = opt[:i], opt[i+1:]
+		}
+
+		switch strings.TrimSpace(key) {
+		case "required":
+			opts.required = true
+		case "style":
+			opts.style = value
+		case "explode":
+			explode = value
+		}
+	}
+
+	switch opts.style {
+	case "":
+		opts.style = defaultStyle(loc, fieldType.Type)
+	case StyleForm, StyleSimple, StyleSpaceDelimited, StylePipeDelimited:
+	case StyleDeepObject:
+		if !isNested(fieldType.Type) {
+			return opts, fmt.Errorf("easybind: style deepObject of field %s must be a struct or map", fieldType.Name)
+		}
+	default:
+		return opts, fmt.Errorf("easybind: unknown style %q of field %s", opts.style, fieldType.Name)
+	}
+
+	switch explode {
+	case "":
+		opts.explode = opts.style == StyleForm || opts.style == StyleDeepObject
+	case "true":
+		opts.explode = true
+	case "false":
+		opts.explode = false
+	default:
+		return opts, fmt.Errorf("easybind: invalid explode %q of field %s", explode, fieldType.Name)
+	}
+
+	return
+}
+
+func defaultStyle(loc string, typ reflect.Type) string {
+	switch loc {
+	case inTagPath, inTagHeader:
+		return StyleSimple
+	case inTagQuery, inTagForm:
+		if isNested(typ) {
+			return StyleDeepObject
+		}
+	}
+	return StyleForm
+}
+
+// isStyledSlice reports whether the values of typ are split by the style, such as []int
+// but not net.IP or []struct.
+func isStyledSlice(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice && !hasTypeBinder(typ) && !isNested(typ)
+}
+
+// splitValues splits every value by the delimiter of the style and trims the elements,
+// exploded values are repeated keys so they are kept as they are.
+func splitValues(values []string, opts tagOptions) []string {
+	var sep string
+	switch {
+	case opts.explode:
+		return values
+	case opts.style == StyleForm, opts.style == StyleSimple:
+		sep = ","
+	case opts.style == StyleSpaceDelimited:
+		sep = " "
+	case opts.style == StylePipeDelimited:
+		sep = "|"
+	default:
+		return values
+	}
+
+	splits := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		for _, split := range strings.Split(value, sep) {
+			splits = append(splits, strings.TrimSpace(split))
+		}
+	}
+	return splits
+}
+
+// lookupValues returns the values of name, values of name[] are also included.
+func lookupValues(values url.Values, name string) []string {
+	if len(values[name+"[]"]) == 0 {
+		return values[name]
+	}
+	return append(append([]string(nil), values[name]...), values[name+"[]"]...)
+}
+
+// Parameter describes a field bound by Bind in OpenAPI terms, so API docs can
+// describe exactly what the binder accepts.
+type Parameter struct {
+	// Name of the parameter, or the key of the cookie, ctx value, form file.
+	Name string
+	// In is the location of `pos` tag: path, query, header, cookie, form, file or ctx.
+	In string
+	// Style and Explode are the OpenAPI serialization of the value.
+	Style   string
+	Explode bool
+	// Required is true if the `pos` tag has the required option.
+	Required bool
+	// Default is the value of `default` tag.
+	Default string
+	// Type of the struct field.
+	Type reflect.Type
+}
+
+// Parameters returns the parameters of the struct v bound by `pos` tags,
+// fields of embedded structs are included, JSON body fields are not.
+func Parameters(v interface{}) ([]Parameter, error) {
+	typ := reflect.TypeOf(v)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("easybind: can't describe nonstruct value %T", v)
+	}
+	return parameters(typ)
+}
+
+func parameters(typ reflect.Type) (params []Parameter, err error) {
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		if fieldType.Anonymous && fieldType.Type.Kind() == reflect.Struct {
+			embedded, err := parameters(fieldType.Type)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, embedded...)
+			continue
+		}
+
+		loc, name := getInTagLocAndName(fieldType)
+		if loc == inTagBody || loc == "" {
+			continue
+		}
+
+		opts, err := getInTagOptions(fieldType)
+		if err != nil {
+			return nil, err
+		}
+
+		params = append(params, Parameter{
+			Name:     name,
+			In:       loc,
+			Style:    opts.style,
+			Explode:  opts.explode,
+			Required: opts.required || loc == inTagPath,
+			Default:  fieldType.Tag.Get(tagNameDefault),
+			Type:     fieldType.Type,
+		})
+	}
+	return
+}
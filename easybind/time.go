@@ -0,0 +1,125 @@
+package easybind
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Tags of time.Time fields
+const (
+	tagNameTimeFormat   = "time_format"
+	tagNameTimeLocation = "time_location"
+	tagNameTimeUnit     = "time_unit"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeOptions are the per-field options of time binding, from tags like
+// `time_format:"2006-01-02" time_location:"UTC"` or `time_unit:"ms"`.
+type timeOptions struct {
+	format string
+	loc    *time.Location
+	unit   time.Duration
+}
+
+// isTimeField reports whether typ is time.Time, *time.Time or []time.Time.
+func isTimeField(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	return typ == timeType
+}
+
+// getTimeOptions returns nil if the field has none of the time tags.
+func getTimeOptions(fieldType reflect.StructField) (*timeOptions, error) {
+	var (
+		format, hasFormat = fieldType.Tag.Lookup(tagNameTimeFormat)
+		location, hasLoc  = fieldType.Tag.Lookup(tagNameTimeLocation)
+		unit, hasUnit     = fieldType.Tag.Lookup(tagNameTimeUnit)
+	)
+
+	if !hasFormat && !hasLoc && !hasUnit {
+		return nil, nil
+	}
+
+	opts := &timeOptions{format: format, loc: time.Local}
+
+	if location != "" {
+		loc, err := time.LoadLocation(location)
+		if err != nil {
+			return nil, fmt.Errorf("easybind: invalid time_location of field %s: %v", fieldType.Name, err)
+		}
+		opts.loc = loc
+	}
+
+	switch unit {
+	case "":
+	case "s":
+		opts.unit = time.Second
+	case "ms":
+		opts.unit = time.Millisecond
+	case "us":
+		opts.unit = time.Microsecond
+	case "ns":
+		opts.unit = time.Nanosecond
+	default:
+		return nil, fmt.Errorf("easybind: invalid time_unit %q of field %s, must be s, ms, us or ns", unit, fieldType.Name)
+	}
+
+	return opts, nil
+}
+
+// parse parses val as epoch in unit, or with the format, or with TimeFormats.
+func (opts *timeOptions) parse(val string) (time.Time, error) {
+	if opts.unit > 0 {
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return time.Time{}, errInvalidValue(val, timeType, err)
+		}
+		perSecond := int64(time.Second / opts.unit)
+		return time.Unix(n/perSecond, n%perSecond*int64(opts.unit)).In(opts.loc), nil
+	}
+
+	if opts.format != "" {
+		t, err := time.ParseInLocation(opts.format, val, opts.loc)
+		if err != nil {
+			return time.Time{}, errInvalidValue(val, timeType, err)
+		}
+		return t, nil
+	}
+
+	t, ok := parseTime(val, opts.loc)
+	if !ok {
+		return time.Time{}, errInvalidValue(val, timeType, nil)
+	}
+	return t, nil
+}
+
+// bind sets the time field with the values, slice fields get all the values appended.
+// Empty values are skipped, it returns the error of an invalid value.
+func (opts *timeOptions) bind(field reflect.Value, values []string) error {
+	for _, val := range values {
+		if val == "" {
+			continue
+		}
+
+		t, err := opts.parse(val)
+		if err != nil {
+			return err
+		}
+
+		switch field.Kind() {
+		case reflect.Ptr:
+			field.Set(reflect.ValueOf(&t))
+			return nil
+		case reflect.Slice:
+			field.Set(reflect.Append(field, reflect.ValueOf(t)))
+		default:
+			field.Set(reflect.ValueOf(t))
+			return nil
+		}
+	}
+	return nil
+}
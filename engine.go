@@ -1,11 +1,25 @@
 package fox
 
 import (
-	"fmt"
+	"context"
+	"html/template"
+	"net"
 	"net/http"
+	"os"
 	"path"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miclle/fox/render"
+)
+
+const (
+	defaultMultipartMemory = 32 << 20 // 32 MB
+	defaultShutdownTimeout = 10 * time.Second
 )
 
 var (
@@ -27,6 +41,17 @@ type HandlerFunc interface{}
 // HandlersChain defines a HandlerFunc slice.
 type HandlersChain []HandlerFunc
 
+// RouteInfo represents a request route's specification which contains method and path and its handler.
+type RouteInfo struct {
+	Method      string
+	Path        string
+	Handler     string
+	HandlerFunc HandlerFunc
+}
+
+// RoutesInfo defines a RouteInfo slice.
+type RoutesInfo []RouteInfo
+
 // Engine is a http.Handler which can be used to dispatch requests to different
 // handler functions via configurable routes
 type Engine struct {
@@ -75,6 +100,10 @@ type Engine struct {
 	// The "Allowed" header is set before calling the handler.
 	GlobalOPTIONS http.Handler
 
+	// Handlers called on automatic OPTIONS requests before GlobalOPTIONS,
+	// e.g. the CORS middleware answering the preflight requests.
+	optionsHandlers HandlersChain
+
 	// Cached value of global (*) allowed methods
 	globalAllowed string
 
@@ -95,6 +124,84 @@ type Engine struct {
 	// The handler can be used to keep your server from crashing because of
 	// unrecovered panics.
 	PanicHandler func(http.ResponseWriter, *http.Request, interface{})
+
+	// Value of 'maxMemory' param that is given to http.Request's ParseMultipartForm
+	// method call. File parts beyond this limit are stored in temporary files.
+	MaxMultipartMemory int64
+
+	// Maximum number of bytes allowed to read from the request body, 0 means
+	// unlimited. Reading beyond the limit fails and the request is answered with
+	// 413 Payload Too Large. Use BodyLimit to override it for a group or route.
+	MaxBodyBytes int64
+
+	// If enabled, JSON bodies with fields that are not in the handler's arguments
+	// struct are rejected with 400 Bad Request. Embed easybind.StrictJSON into an
+	// arguments struct to enable it for a single struct.
+	StrictJSON bool
+
+	// If enabled, JSON numbers bound into interface{} values are decoded as
+	// json.Number instead of float64, so IDs over 2^53 keep their precision.
+	UseJSONNumber bool
+
+	// Maximum duration to drain in-flight requests when the context of RunContext
+	// is done, 0 means waiting until all the requests are finished.
+	ShutdownTimeout time.Duration
+
+	// If enabled, the cleartext Run methods accept HTTP/2 without TLS (h2c), both with
+	// prior knowledge and upgraded from HTTP/1.1, e.g. behind a service mesh sidecar.
+	// RunTLS negotiates HTTP/2 by ALPN whatever it is.
+	H2C bool
+
+	// If set, the unix socket file of RunUnix is chmod to it, e.g. 0660 to allow
+	// the users of the group to connect.
+	UnixSocketMode os.FileMode
+
+	// HTMLRender renders the render.HTML responses without Template, it is set by
+	// LoadHTMLGlob, LoadHTMLFiles and SetHTMLTemplate.
+	HTMLRender render.HTMLRender
+
+	// FuncMap are the funcs of the templates, including the template funcs of the requests,
+	// e.g. csrfToken of the CSRF middleware. Pass it to the templates set by SetHTMLTemplate.
+	// The funcs of the requests are bound to the pooled clones of the templates loaded through
+	// the engine, and to a clone of the render.HTML Template on every render if it isn't
+	// executed yet, an executed one can't be cloned.
+	FuncMap template.FuncMap
+	delims  render.Delims
+
+	// htmlTemplates is the pool of the clones of the templates of HTMLProduction, their
+	// template funcs call the funcs of the request being rendered, see Context.htmlRender.
+	htmlTemplates *sync.Pool
+
+	// Logger writes the framework messages, e.g. debug output, server errors and recovered
+	// panics, DefaultLogger by default. Context.Logger returns a child logger of it.
+	Logger Logger
+
+	// RemoteIPHeaders are the headers used to obtain the client IP when the remote address
+	// is a trusted proxy, see SetTrustedProxies and Context.ClientIP.
+	RemoteIPHeaders []string
+	trustedCIDRs    []*net.IPNet
+
+	// Settings of the http.Server created by the Run methods, New sets the timeouts
+	// and header size limit against slow clients.
+	Server ServerConfig
+
+	// routeGroups is the group registering each route, keyed by method and full path,
+	// boundGroups holds the map[*RouterGroup]int of the groups served by their own
+	// endpoints while RunListeners is running. It's replaced, not modified, so requests read it without lock.
+	routeGroups map[string]*RouterGroup
+	boundGroups atomic.Value
+
+	// routes are the registered routes in order, routeHooks are called with every route.
+	routes     RoutesInfo
+	routeHooks []func(RouteInfo)
+
+	// mu protects the running servers and the start and shutdown hooks.
+	mu            sync.Mutex
+	servers       map[*http.Server]struct{}
+	startHooks    []func(context.Context) error
+	shutdownHooks []func(context.Context) error
+	stopped       bool // the shutdown hooks ran since the start hooks
+	draining      sync.WaitGroup
 }
 
 // Make sure the Router conforms with the http.Handler interface
@@ -113,7 +220,16 @@ func New() *Engine {
 		RedirectFixedPath:      true,
 		HandleMethodNotAllowed: true,
 		HandleOPTIONS:          true,
+		MaxMultipartMemory:     defaultMultipartMemory,
+		ShutdownTimeout:        defaultShutdownTimeout,
+		Server:                 defaultServerConfig(),
+		FuncMap:                mergeFuncMaps(templateFuncs),
+		RemoteIPHeaders:        []string{"X-Forwarded-For", "X-Real-IP"},
+		trustedCIDRs:           defaultTrustedCIDRs(),
+		delims:                 render.Delims{Left: "{{", Right: "}}"},
+		Logger:                 DefaultLogger(),
 	}
+	debugPrintWARNINGNew(engine.Logger)
 	engine.RouterGroup.engine = engine
 	engine.pool.New = func() any {
 		return engine.allocateContext()
@@ -144,6 +260,17 @@ func (engine *Engine) NoMethod(handlers ...HandlerFunc) {
 	engine.methodNotAllowedHandlers = handlers
 }
 
+// AutoOPTIONS sets the handlers called on the automatic replies to OPTIONS requests, the
+// "Allow" header is set before the handlers are called. GlobalOPTIONS is called after them
+// unless the response is written.
+//
+//	cors := fox.CORS(config)
+//	router.Use(cors)
+//	router.AutoOPTIONS(cors)
+func (engine *Engine) AutoOPTIONS(handlers ...HandlerFunc) {
+	engine.optionsHandlers = handlers
+}
+
 func (engine *Engine) addRoute(method, path string, handlers HandlersChain) {
 
 	varsCount := uint16(0)
@@ -172,8 +299,19 @@ func (engine *Engine) addRoute(method, path string, handlers HandlersChain) {
 		engine.globalAllowed = engine.allowed("*", "")
 	}
 
+	debugPrintRoute(engine.Logger, method, path, handlers)
 	root.addRoute(path, handlers)
 
+	route := RouteInfo{Method: method, Path: path}
+	if len(handlers) > 0 {
+		route.HandlerFunc = handlers[len(handlers)-1]
+		route.Handler = getFunctionName(route.HandlerFunc)
+	}
+	engine.routes = append(engine.routes, route)
+	for _, hook := range engine.routeHooks {
+		hook(route)
+	}
+
 	// Update maxParams
 	if paramsCount := countParams(path); paramsCount+varsCount > engine.maxParams {
 		engine.maxParams = paramsCount + varsCount
@@ -188,13 +326,123 @@ func (engine *Engine) addRoute(method, path string, handlers HandlersChain) {
 	}
 }
 
-func (engine *Engine) recv(w http.ResponseWriter, req *http.Request) {
+// defaultTrustedProxies are the loopback addresses, e.g. a reverse proxy on the same host.
+var defaultTrustedProxies = []string{"127.0.0.1/8", "::1/128"}
+
+func defaultTrustedCIDRs() []*net.IPNet {
+	cidrs, _ := parseCIDRs(defaultTrustedProxies)
+	return cidrs
+}
+
+// SetTrustedProxies sets the IP addresses or CIDRs of the proxies whose RemoteIPHeaders
+// are trusted by Context.ClientIP, the loopback addresses are trusted by default.
+// nil disables the headers, the client IP is always the remote address then.
+func (engine *Engine) SetTrustedProxies(trustedProxies []string) error {
+	cidrs, err := parseCIDRs(trustedProxies)
+	if err != nil {
+		return err
+	}
+	engine.trustedCIDRs = cidrs
+	return nil
+}
+
+// parseCIDRs parses the IP addresses and CIDRs, an IP address is a CIDR of a single address.
+func parseCIDRs(proxies []string) ([]*net.IPNet, error) {
+	cidrs := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: proxy}
+			}
+
+			bits := net.IPv6len * 8
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, net.IPv4len*8
+			}
+			proxy = ip.String() + "/" + strconv.Itoa(bits)
+		}
+
+		_, cidr, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+// isTrustedProxy reports whether ip is in one of the trusted CIDRs.
+func (engine *Engine) isTrustedProxy(ip net.IP) bool {
+	for _, cidr := range engine.trustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateHeader returns the client IP of the X-Forwarded-For like header, the addresses
+// are walked from right to left and the first one that is not a trusted proxy is the client.
+func (engine *Engine) validateHeader(header string) (clientIP string, valid bool) {
+	if header == "" {
+		return "", false
+	}
+
+	items := strings.Split(header, ",")
+	for i := len(items) - 1; i >= 0; i-- {
+		ipStr := strings.TrimSpace(items[i])
+		ip := net.ParseIP(ipStr)
+		if ip == nil {
+			break
+		}
+
+		// X-Forwarded-For is appended by proxy
+		// Check IPs in reverse order and stop when find untrusted proxy
+		if i == 0 || !engine.isTrustedProxy(ip) {
+			return ipStr, true
+		}
+	}
+	return "", false
+}
+
+// Routes returns a slice of registered routes, including some useful information, such as:
+// the http method, path and the handler name.
+func (engine *Engine) Routes() RoutesInfo {
+	return append(RoutesInfo(nil), engine.routes...)
+}
+
+// OnRoute registers a hook which is called with every route added to the engine, e.g. to
+// register metrics labels or ACL entries. The routes registered before are passed to the
+// hook at once, so it sees all the routes whenever it is registered.
+func (engine *Engine) OnRoute(hook func(RouteInfo)) {
+	engine.routeHooks = append(engine.routeHooks, hook)
+	for _, route := range engine.routes {
+		hook(route)
+	}
+}
+
+// logger returns engine.Logger, or DefaultLogger if it is nil.
+func (engine *Engine) logger() Logger {
+	if engine.Logger == nil {
+		return DefaultLogger()
+	}
+	return engine.Logger
+}
+
+func (engine *Engine) recv(ctx *Context) {
 	if rcv := recover(); rcv != nil {
-		engine.PanicHandler(w, req, rcv)
+		ctx.Logger().Error("panic recovered", "error", rcv, "stack", string(debug.Stack()))
+		engine.PanicHandler(ctx.Writer, ctx.Request, rcv)
 	}
 }
 
 func (engine *Engine) allowed(path, reqMethod string) (allow string) {
+	return engine.allowedIn(nil, path, reqMethod)
+}
+
+// allowedIn is like allowed, but only the routes served by the endpoint of scope are included.
+func (engine *Engine) allowedIn(scope *RouterGroup, path, reqMethod string) (allow string) {
 	allowed := make([]string, 0, 9)
 
 	if path == "*" { // server-wide
@@ -217,8 +465,8 @@ func (engine *Engine) allowed(path, reqMethod string) (allow string) {
 				continue
 			}
 
-			handle, _, _ := engine.trees[method].getValue(path, nil)
-			if handle != nil {
+			handle, _, _, fullPath := engine.trees[method].getValue(path, nil)
+			if handle != nil && engine.serves(scope, method, fullPath) {
 				// Add request method to list of allowed methods
 				allowed = append(allowed, method)
 			}
@@ -247,39 +495,49 @@ func (engine *Engine) allowed(path, reqMethod string) (allow string) {
 
 // Run attaches the router to a http.Server and starts listening and serving HTTP requests.
 // It is a shortcut for http.ListenAndServe(addr, router)
-// Note: this method will block the calling goroutine indefinitely unless an error happens.
+// Note: this method will block the calling goroutine indefinitely unless an error happens
+// or the engine is shut down, see RunContext and Shutdown.
 func (engine *Engine) Run(addr string) (err error) {
-	defer func() {
-		if err != nil {
-			fmt.Fprintf(DefaultErrorWriter, "[ERROR] %v\n", err)
-		}
-	}()
-
-	err = http.ListenAndServe(addr, engine)
-	return
+	return engine.RunContext(context.Background(), addr)
 }
 
 // ServeHTTP makes the router implement the http.Handler interface.
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	engine.serveHTTP(w, req, nil)
+}
+
+// serveHTTP serves the request with the routes of the endpoint of scope, see RunListeners.
+func (engine *Engine) serveHTTP(w http.ResponseWriter, req *http.Request, scope *RouterGroup) {
 	ctx := engine.pool.Get().(*Context)
 	ctx.reset(w, req)
+	ctx.scope = scope
+	// remove the temporary files even if the handler panics, before ctx is reused
+	defer func() {
+		ctx.removeMultipartFiles()
+		engine.pool.Put(ctx)
+	}()
 	engine.handleHTTPRequest(ctx)
-	engine.pool.Put(ctx)
 }
 
 func (engine *Engine) handleHTTPRequest(ctx *Context) {
 	if engine.PanicHandler != nil {
-		defer engine.recv(ctx.Writer, ctx.Request)
+		defer engine.recv(ctx)
+	}
+
+	if engine.MaxBodyBytes > 0 {
+		ctx.limitBody(engine.MaxBodyBytes)
 	}
 
 	httpMethod := ctx.Request.Method
 	path := ctx.Request.URL.Path
 
 	if root := engine.trees[httpMethod]; root != nil {
-		handlers, ps, tsr := root.getValue(path, ctx.Params)
+		handlers, ps, tsr, fullPath := root.getValue(path, ctx.Params)
+		served := engine.serves(ctx.scope, httpMethod, fullPath)
 
-		if handlers != nil {
+		if handlers != nil && served {
 			ctx.handlers = handlers
+			ctx.fullPath = fullPath
 			if ps != nil {
 				ctx.Params = ps
 			}
@@ -289,7 +547,7 @@ func (engine *Engine) handleHTTPRequest(ctx *Context) {
 		}
 
 		if httpMethod != http.MethodConnect && path != "/" {
-			if tsr && engine.RedirectTrailingSlash {
+			if tsr && engine.RedirectTrailingSlash && engine.servesPath(ctx.scope, root, httpMethod, trailingSlashPath(path)) {
 				redirectTrailingSlash(ctx)
 				return
 			}
@@ -302,18 +560,23 @@ func (engine *Engine) handleHTTPRequest(ctx *Context) {
 
 	// Handle OPTIONS requests
 	if httpMethod == http.MethodOptions && engine.HandleOPTIONS {
-		if allow := engine.allowed(path, http.MethodOptions); allow != "" {
+		if allow := engine.allowedIn(ctx.scope, path, http.MethodOptions); allow != "" {
 			ctx.Writer.Header().Set("Allow", allow)
-			if engine.GlobalOPTIONS != nil {
+			if len(engine.optionsHandlers) > 0 {
+				ctx.handlers = engine.optionsHandlers
+				ctx.Next()
+			}
+			if engine.GlobalOPTIONS != nil && !ctx.Writer.Written() {
 				engine.GlobalOPTIONS.ServeHTTP(ctx.Writer, ctx.Request)
 			}
+			ctx.Writer.WriteHeaderNow()
 			return
 		}
 	}
 
 	// Handle 405
 	if engine.HandleMethodNotAllowed {
-		if allow := engine.allowed(path, httpMethod); allow != "" {
+		if allow := engine.allowedIn(ctx.scope, path, httpMethod); allow != "" {
 			ctx.Writer.Header().Set("Allow", allow)
 			ctx.handlers = engine.methodNotAllowedHandlers
 			serveError(ctx, http.StatusMethodNotAllowed, default405Body)
@@ -326,6 +589,70 @@ func (engine *Engine) handleHTTPRequest(ctx *Context) {
 	serveError(ctx, http.StatusNotFound, default404Body)
 }
 
+// serves reports whether the route is served by the endpoint of scope. Routes of a bound
+// group are only served by its endpoint, the other routes by the endpoints without group.
+func (engine *Engine) serves(scope *RouterGroup, method, fullPath string) bool {
+	bound := engine.bound()
+	if len(bound) == 0 {
+		return true
+	}
+
+	for group := engine.routeGroups[method+" "+fullPath]; group != nil; group = group.parent {
+		if _, ok := bound[group]; ok {
+			return group == scope
+		}
+	}
+	return scope == nil
+}
+
+// servesPath reports whether the route matching path is served by the endpoint of scope,
+// so the redirects never point to the routes of another endpoint.
+func (engine *Engine) servesPath(scope *RouterGroup, root *node, method, path string) bool {
+	if len(engine.bound()) == 0 {
+		return true
+	}
+
+	handlers, _, _, fullPath := root.getValue(path, nil)
+	return handlers != nil && engine.serves(scope, method, fullPath)
+}
+
+// bound returns the groups served by their own endpoints, with the number of the running
+// RunListeners binding them.
+func (engine *Engine) bound() map[*RouterGroup]int {
+	bound, _ := engine.boundGroups.Load().(map[*RouterGroup]int)
+	return bound
+}
+
+// bindGroups adds groups to the groups served by their own endpoints, nil groups are ignored.
+// The returned unbind removes them, so the routes are served by the default endpoints again.
+func (engine *Engine) bindGroups(groups ...*RouterGroup) (unbind func()) {
+	engine.updateBound(groups, 1)
+	return func() {
+		engine.updateBound(groups, -1)
+	}
+}
+
+// updateBound adds delta to the bindings of groups, groups without binding are removed.
+func (engine *Engine) updateBound(groups []*RouterGroup, delta int) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	old := engine.bound()
+	bound := make(map[*RouterGroup]int, len(old)+len(groups))
+	for group, n := range old {
+		bound[group] = n
+	}
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		if bound[group] += delta; bound[group] <= 0 {
+			delete(bound, group)
+		}
+	}
+	engine.boundGroups.Store(bound)
+}
+
 var mimePlain = []string{"text/plain"}
 
 func serveError(c *Context, code int, defaultMessage []byte) {
@@ -338,13 +665,21 @@ func serveError(c *Context, code int, defaultMessage []byte) {
 		c.Writer.Header()["Content-Type"] = mimePlain
 		_, err := c.Writer.Write(defaultMessage)
 		if err != nil {
-			// debugPrint("cannot write message to writer during serve error: %v", err)
+			debugPrint(c.engine.Logger, "cannot write message to writer during serve error: %v", err)
 		}
 		return
 	}
 	c.Writer.WriteHeaderNow()
 }
 
+// trailingSlashPath returns p with the trailing slash added or removed.
+func trailingSlashPath(p string) string {
+	if length := len(p); length > 1 && p[length-1] == '/' {
+		return p[:length-1]
+	}
+	return p + "/"
+}
+
 func redirectTrailingSlash(ctx *Context) {
 	req := ctx.Request
 	p := req.URL.Path
@@ -363,6 +698,9 @@ func redirectFixedPath(ctx *Context, root *node, trailingSlash bool) bool {
 	rPath := req.URL.Path
 
 	if fixedPath, ok := root.findCaseInsensitivePath(CleanPath(rPath), trailingSlash); ok {
+		if !ctx.engine.servesPath(ctx.scope, root, req.Method, fixedPath) {
+			return false
+		}
 		req.URL.Path = fixedPath
 		redirectRequest(ctx)
 		return true
@@ -372,14 +710,14 @@ func redirectFixedPath(ctx *Context, root *node, trailingSlash bool) bool {
 
 func redirectRequest(ctx *Context) {
 	req := ctx.Request
-	// rPath := req.URL.Path
+	rPath := req.URL.Path
 	rURL := req.URL.String()
 
 	code := http.StatusMovedPermanently // Permanent redirect, request with GET method
 	if req.Method != http.MethodGet {
 		code = http.StatusTemporaryRedirect
 	}
-	// debugPrint("redirecting request %d: %s --> %s", code, rPath, rURL)
+	debugPrint(ctx.engine.Logger, "redirecting request %d: %s --> %s", code, rPath, rURL)
 	http.Redirect(ctx.Writer, req, rURL, code)
 	ctx.Writer.WriteHeaderNow()
 }
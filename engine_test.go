@@ -217,3 +217,74 @@ func TestEngineRESTful(t *testing.T) {
 		}
 	}
 }
+
+func TestEngineBindContextValue(t *testing.T) {
+	router := New()
+
+	type User struct {
+		Name string
+	}
+	type Args struct {
+		User  *User `pos:"ctx:user"`
+		Limit int   `pos:"query:limit" default:"20"`
+	}
+
+	auth := func(c *Context) {
+		c.Set("user", &User{Name: "fox"})
+	}
+	router.GET("/me", auth, func(c *Context, args *Args) string {
+		return fmt.Sprintf("%s %d", args.User.Name, args.Limit)
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/me", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "fox 20", w.Body.String())
+}
+
+func TestEngineStrictJSON(t *testing.T) {
+	router := New()
+	router.StrictJSON = true
+
+	type CreateArgs struct {
+		Email string `json:"email"`
+	}
+	router.POST("/users", func(c *Context, args *CreateArgs) string {
+		return args.Email
+	})
+
+	w := PerformRequest(router, http.MethodPost, "/users", nil, strings.NewReader(`{"email":"fox@hello.world"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "fox@hello.world", w.Body.String())
+
+	w = PerformRequest(router, http.MethodPost, "/users", nil, strings.NewReader(`{"emial":"fox@hello.world"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `json: unknown field "emial"`, w.Body.String())
+}
+
+func TestEngineOnRoute(t *testing.T) {
+	router := New()
+	router.GET("/users", handlerNameTest)
+
+	var routes []RouteInfo
+	router.OnRoute(func(route RouteInfo) {
+		routes = append(routes, route)
+	})
+	router.Group("/admin").POST("/users/:id", func(c *Context) {}, handlerNameTest)
+
+	if assert.Len(t, routes, 2) {
+		assert.Equal(t, "GET", routes[0].Method)
+		assert.Equal(t, "/users", routes[0].Path)
+		assert.Equal(t, "github.com/miclle/fox.handlerNameTest", routes[0].Handler)
+
+		assert.Equal(t, "POST", routes[1].Method)
+		assert.Equal(t, "/admin/users/:id", routes[1].Path)
+		assert.Equal(t, "github.com/miclle/fox.handlerNameTest", routes[1].Handler)
+		assert.NotNil(t, routes[1].HandlerFunc)
+	}
+
+	infos := router.Routes()
+	if assert.Len(t, infos, 2) {
+		assert.Equal(t, "/users", infos[0].Path)
+		assert.Equal(t, "/admin/users/:id", infos[1].Path)
+	}
+}
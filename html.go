@@ -0,0 +1,223 @@
+package fox
+
+import (
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/miclle/fox/render"
+)
+
+// Delims sets template left and right delims and returns an Engine instance.
+func (engine *Engine) Delims(left, right string) *Engine {
+	engine.delims = render.Delims{Left: left, Right: right}
+	return engine
+}
+
+// LoadHTMLGlob loads HTML files identified by glob pattern and associates the result
+// with HTML renderer. In debug mode the templates are reloaded on every request.
+func (engine *Engine) LoadHTMLGlob(pattern string) {
+	left := engine.delims.Left
+	right := engine.delims.Right
+	templ := template.Must(template.New("").Delims(left, right).Funcs(engine.funcMap()).ParseGlob(pattern))
+
+	if IsDebugging() {
+		debugPrintLoadTemplate(engine.Logger, templ)
+		engine.HTMLRender = render.HTMLDebug{Glob: pattern, FuncMap: engine.funcMap(), Delims: engine.delims}
+		engine.htmlTemplates = nil
+		return
+	}
+
+	engine.SetHTMLTemplate(templ)
+}
+
+// LoadHTMLFiles loads a slice of HTML files and associates the result with HTML renderer.
+func (engine *Engine) LoadHTMLFiles(files ...string) {
+	if IsDebugging() {
+		engine.HTMLRender = render.HTMLDebug{Files: files, FuncMap: engine.funcMap(), Delims: engine.delims}
+		engine.htmlTemplates = nil
+		return
+	}
+
+	templ := template.Must(template.New("").Delims(engine.delims.Left, engine.delims.Right).Funcs(engine.funcMap()).ParseFiles(files...))
+	engine.SetHTMLTemplate(templ)
+}
+
+// SetHTMLTemplate associate a template with HTML renderer.
+func (engine *Engine) SetHTMLTemplate(templ *template.Template) {
+	if len(engine.trees) > 0 {
+		debugPrintWARNINGSetHTMLTemplate(engine.Logger)
+	}
+
+	templ = templ.Funcs(engine.funcMap())
+
+	// an executed template can't be cloned
+	engine.htmlTemplates = nil
+	if clone, err := templ.Clone(); err == nil {
+		engine.htmlTemplates = newRequestTemplatePool(clone)
+	}
+
+	engine.HTMLRender = render.HTMLProduction{Template: templ}
+}
+
+// SetFuncMap sets the FuncMap used for template.FuncMap.
+func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
+	engine.FuncMap = funcMap
+}
+
+// templateFuncs are the funcs of every template, their results depend on the request,
+// e.g. csrfToken returns the CSRF token of the CSRF middleware and cspNonce returns the
+// Content-Security-Policy nonce of the Secure middleware.
+var templateFuncs = template.FuncMap{
+	"csrfToken": func() string { return "" },
+	"cspNonce":  func() string { return "" },
+}
+
+// funcMap returns templateFuncs and FuncMap, so the templates parse even if FuncMap is
+// replaced by SetFuncMap.
+func (engine *Engine) funcMap() template.FuncMap {
+	return mergeFuncMaps(templateFuncs, engine.FuncMap)
+}
+
+// mergeFuncMaps returns a new FuncMap of the funcs of maps, the later funcs take precedence.
+func mergeFuncMaps(maps ...template.FuncMap) template.FuncMap {
+	merged := make(template.FuncMap)
+	for _, funcMap := range maps {
+		for name, fn := range funcMap {
+			merged[name] = fn
+		}
+	}
+	return merged
+}
+
+// htmlRender returns the render of v, the templates of engine.HTMLRender are used if v has
+// no Template. The missing templates are only warned about in debug mode, the render fails
+// the same way in every mode.
+func (c *Context) htmlRender(v render.HTML) render.Render {
+	var r render.Render = v
+	if v.Template == nil {
+		if c.engine.HTMLRender == nil {
+			debugPrintWARNING(c.engine.Logger, "No HTML templates are loaded, call LoadHTMLGlob or LoadHTMLFiles to render %q", v.Name)
+			return v
+		}
+
+		r = c.engine.HTMLRender.Instance(v.Name, v.Data)
+		html, ok := r.(render.HTML)
+		if !ok {
+			return r
+		}
+
+		if len(c.templateFuncs) > 0 {
+			r = c.bindTemplateFuncs(html)
+		}
+		v = html
+	} else if len(c.templateFuncs) > 0 {
+		v.Template = c.cloneTemplateFuncs(v.Template)
+		r = v
+	}
+
+	if v.Name != "" && v.Template.Lookup(v.Name) == nil {
+		debugPrintWARNING(c.engine.Logger, "HTML template %q is not defined, defined templates:%s", v.Name, v.Template.DefinedTemplates())
+	}
+	return r
+}
+
+// setTemplateFunc sets the template func of name for the request, it replaces the func of
+// templateFuncs when the templates are rendered.
+func (c *Context) setTemplateFunc(name string, fn func() string) {
+	if c.templateFuncs == nil {
+		c.templateFuncs = make(map[string]func() string)
+	}
+	c.templateFuncs[name] = fn
+}
+
+// templateFunc calls the template func name of the request, "" if it has none.
+func (c *Context) templateFunc(name string) string {
+	if fn := c.templateFuncs[name]; fn != nil {
+		return fn()
+	}
+	return ""
+}
+
+// requestFuncs returns the template funcs calling the funcs of the request c returns.
+func requestFuncs(c func() *Context) template.FuncMap {
+	funcs := make(template.FuncMap, len(templateFuncs))
+	for name := range templateFuncs {
+		name := name
+		funcs[name] = func() string { return c().templateFunc(name) }
+	}
+	return funcs
+}
+
+// bindTemplateFuncs binds the template funcs of the request to the templates loaded through
+// the engine. The production templates are shared by the requests, so a clone is taken from
+// engine.htmlTemplates and put back once rendered, the templates are cloned once per
+// concurrent render rather than once per request. The debug templates are parsed for every
+// render, the funcs are bound to them as they are.
+func (c *Context) bindTemplateFuncs(v render.HTML) render.Render {
+	if _, ok := c.engine.HTMLRender.(render.HTMLDebug); ok {
+		v.Template = v.Template.Funcs(requestFuncs(func() *Context { return c }))
+		return v
+	}
+
+	pool := c.engine.htmlTemplates
+	if html, ok := c.engine.HTMLRender.(render.HTMLProduction); !ok || html.Template != v.Template || pool == nil {
+		v.Template = c.cloneTemplateFuncs(v.Template)
+		return v
+	}
+
+	t := pool.Get().(*requestTemplate)
+	t.c = c
+	v.Template = t.Template
+	return requestHTML{HTML: v, pool: pool, template: t}
+}
+
+// cloneTemplateFuncs returns a clone of t with the template funcs of the request, it's used
+// for a render.HTML Template, which is cloned on every render. A template which can't be
+// cloned, e.g. one executed before, is returned as it is, so its funcs return "" and the
+// forms posting its csrfToken are rejected.
+func (c *Context) cloneTemplateFuncs(t *template.Template) *template.Template {
+	clone, err := t.Clone()
+	if err != nil {
+		debugPrintWARNING(c.engine.Logger, "The template funcs of the request, e.g. csrfToken, are not bound: %v. "+
+			"Load the templates with LoadHTMLGlob, LoadHTMLFiles or SetHTMLTemplate, or pass a render.HTML Template "+
+			"which is not executed yet", err)
+		return t
+	}
+	return clone.Funcs(requestFuncs(func() *Context { return c }))
+}
+
+// requestTemplate is a clone of the production templates, its template funcs call the funcs
+// of the request being rendered.
+type requestTemplate struct {
+	*template.Template
+	c *Context
+}
+
+// newRequestTemplatePool returns the pool of the clones of source, which must never be executed.
+func newRequestTemplatePool(source *template.Template) *sync.Pool {
+	return &sync.Pool{
+		New: func() any {
+			t := &requestTemplate{}
+			// source is unexecuted, the clone never fails
+			t.Template = template.Must(source.Clone()).Funcs(requestFuncs(func() *Context { return t.c }))
+			return t
+		},
+	}
+}
+
+// requestHTML renders with a requestTemplate and puts it back to the pool once rendered.
+type requestHTML struct {
+	render.HTML
+	pool     *sync.Pool
+	template *requestTemplate
+}
+
+// Render (requestHTML) executes the template, the funcs call the funcs of the request.
+func (r requestHTML) Render(w http.ResponseWriter) error {
+	defer func() {
+		r.template.c = nil
+		r.pool.Put(r.template)
+	}()
+	return r.HTML.Render(w)
+}
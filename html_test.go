@@ -0,0 +1,91 @@
+package fox
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/miclle/fox/render"
+)
+
+func TestEngineLoadHTMLGlob(t *testing.T) {
+	for _, mode := range []string{DebugMode, ReleaseMode} {
+		captureOutput(t, mode, func() {
+			router := New()
+			router.Delims("{[{", "}]}")
+			router.SetFuncMap(template.FuncMap{
+				"formatAsDate": func(t time.Time) string {
+					return t.Format("2006/01/02")
+				},
+			})
+			router.LoadHTMLGlob("./testdata/template/*")
+			router.GET("/test", func() render.HTML {
+				return render.HTML{Name: "hello.tmpl", Data: map[string]any{"name": "world"}}
+			})
+			router.GET("/raw", func() render.HTML {
+				return render.HTML{Name: "raw.tmpl", Data: map[string]any{"now": time.Date(2017, 07, 01, 0, 0, 0, 0, time.UTC)}}
+			})
+
+			w := PerformRequest(router, http.MethodGet, "/test", nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "<h1>Hello world</h1>", w.Body.String())
+			assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+			w = PerformRequest(router, http.MethodGet, "/raw", nil)
+			assert.Equal(t, "Date: 2017/07/01\n", w.Body.String())
+		})
+	}
+}
+
+func TestEngineLoadHTMLFiles(t *testing.T) {
+	router := New()
+	router.Delims("{[{", "}]}")
+	router.LoadHTMLFiles("./testdata/template/hello.tmpl")
+	router.GET("/test", func() render.HTML {
+		return render.HTML{Name: "hello.tmpl", Data: map[string]any{"name": "fox"}}
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/test", nil)
+	assert.Equal(t, "<h1>Hello fox</h1>", w.Body.String())
+}
+
+func TestEngineTemplateFuncsConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(`<script nonce="{{ cspNonce }}"></script>`), 0o600))
+
+	// the debug templates are parsed for every render, the production ones are pooled clones
+	for _, mode := range []string{DebugMode, ReleaseMode} {
+		captureOutput(t, mode, func() {
+			router := New()
+			router.LoadHTMLGlob(filepath.Join(dir, "*"))
+			router.Use(Secure())
+			router.GET("/", func(c *Context) render.HTML {
+				return render.HTML{Name: "page.tmpl"}
+			})
+
+			// the templates call the funcs of the request being rendered
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 10; j++ {
+						w := PerformRequest(router, http.MethodGet, "/", nil)
+						matches := regexp.MustCompile(`<script nonce="([^"]+)">`).FindStringSubmatch(w.Body.String())
+						if assert.Len(t, matches, 2, w.Body.String()) {
+							assert.Contains(t, w.Header().Get("Content-Security-Policy"), "'nonce-"+matches[1]+"'")
+						}
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package fox
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger is a leveled logger with key/value fields, fox writes the framework messages,
+// e.g. debug output, server and render errors, recovered panics, through Engine.Logger.
+// The keysAndValues are alternating keys and values, e.g. "status", 200, "path", "/".
+// Adapt a structured logger like slog, zap or zerolog to it to collect fox's messages.
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+	Error(msg string, keysAndValues ...any)
+
+	// With returns a child logger which adds the key/value fields to every message.
+	With(keysAndValues ...any) Logger
+}
+
+// LogLevel is the level of the messages written by the Logger of NewLogger.
+type LogLevel int
+
+const (
+	// LevelDebug is the level of debug output, it is written only in debug mode.
+	LevelDebug LogLevel = iota
+	// LevelInfo is the level of informational messages.
+	LevelInfo
+	// LevelWarn is the level of warnings.
+	LevelWarn
+	// LevelError is the level of errors.
+	LevelError
+)
+
+// String returns the name of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// DefaultLogger returns the Logger of New, it writes the debug output in debug mode and
+// the warnings to DefaultWriter, the errors to DefaultErrorWriter:
+//
+//	[FOX-debug] GET    /users/:id    --> main.getUser (1 handlers)
+//	[ERROR] listen tcp :8080: bind: address already in use
+func DefaultLogger() Logger {
+	return defaultLogger{}
+}
+
+type defaultLogger struct {
+	fields []any
+}
+
+func (l defaultLogger) Debug(msg string, keysAndValues ...any) {
+	if IsDebugging() {
+		writeLogLine(DefaultWriter, "[FOX-debug] ", msg, l.fields, keysAndValues)
+	}
+}
+
+func (l defaultLogger) Info(msg string, keysAndValues ...any) {
+	writeLogLine(DefaultWriter, "[FOX] ", msg, l.fields, keysAndValues)
+}
+
+func (l defaultLogger) Warn(msg string, keysAndValues ...any) {
+	writeLogLine(DefaultWriter, "[FOX] [WARNING] ", msg, l.fields, keysAndValues)
+}
+
+func (l defaultLogger) Error(msg string, keysAndValues ...any) {
+	writeLogLine(DefaultErrorWriter, "[ERROR] ", msg, l.fields, keysAndValues)
+}
+
+func (l defaultLogger) With(keysAndValues ...any) Logger {
+	return defaultLogger{fields: appendFields(l.fields, keysAndValues)}
+}
+
+// NewLogger returns a Logger writing the messages of level and above to out as logfmt lines:
+//
+//	time=2006-01-02T15:04:05.000Z07:00 level=INFO msg="server started" addr=:8080
+func NewLogger(out io.Writer, level LogLevel) Logger {
+	return &textLogger{out: out, level: level, mu: new(sync.Mutex)}
+}
+
+type textLogger struct {
+	out    io.Writer
+	level  LogLevel
+	fields []any
+	mu     *sync.Mutex
+}
+
+func (l *textLogger) Debug(msg string, keysAndValues ...any) {
+	l.log(LevelDebug, msg, keysAndValues)
+}
+
+func (l *textLogger) Info(msg string, keysAndValues ...any) {
+	l.log(LevelInfo, msg, keysAndValues)
+}
+
+func (l *textLogger) Warn(msg string, keysAndValues ...any) {
+	l.log(LevelWarn, msg, keysAndValues)
+}
+
+func (l *textLogger) Error(msg string, keysAndValues ...any) {
+	l.log(LevelError, msg, keysAndValues)
+}
+
+func (l *textLogger) With(keysAndValues ...any) Logger {
+	child := *l
+	child.fields = appendFields(l.fields, keysAndValues)
+	return &child
+}
+
+func (l *textLogger) log(level LogLevel, msg string, keysAndValues []any) {
+	if level < l.level {
+		return
+	}
+
+	var buf strings.Builder
+	buf.WriteString("time=")
+	buf.WriteString(time.Now().Format("2006-01-02T15:04:05.000Z07:00"))
+	buf.WriteString(" level=")
+	buf.WriteString(level.String())
+	writeField(&buf, "msg", msg)
+	writeFields(&buf, l.fields)
+	writeFields(&buf, keysAndValues)
+	buf.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.out, buf.String())
+}
+
+// writeLogLine writes the message with the fields as a single line.
+func writeLogLine(out io.Writer, prefix, msg string, fields, keysAndValues []any) {
+	var buf strings.Builder
+	buf.WriteString(prefix)
+	buf.WriteString(strings.TrimRight(msg, "\n"))
+	writeFields(&buf, fields)
+	writeFields(&buf, keysAndValues)
+	buf.WriteByte('\n')
+	io.WriteString(out, buf.String())
+}
+
+// appendFields returns a new slice of fields followed by keysAndValues, so the fields
+// of the parent logger aren't shared with its children.
+func appendFields(fields, keysAndValues []any) []any {
+	merged := make([]any, 0, len(fields)+len(keysAndValues))
+	return append(append(merged, fields...), keysAndValues...)
+}
+
+func writeFields(buf *strings.Builder, keysAndValues []any) {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		if i+1 == len(keysAndValues) {
+			writeField(buf, "!BADKEY", key)
+			break
+		}
+		writeField(buf, key, keysAndValues[i+1])
+	}
+}
+
+func writeField(buf *strings.Builder, key string, value any) {
+	s := fmt.Sprint(value)
+	if err, ok := value.(error); ok && err != nil {
+		s = err.Error()
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		s = fmt.Sprintf("%q", s)
+	}
+	buf.WriteString(s)
+}
+
+// logWriter writes the lines of a log.Logger to Logger as errors, e.g. http.Server.ErrorLog.
+type logWriter struct {
+	logger Logger
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	w.logger.Error(strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
@@ -0,0 +1,146 @@
+package fox
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/miclle/fox/render"
+)
+
+// recordLogger records the messages as "LEVEL msg k=v" lines.
+type recordLogger struct {
+	mu     *sync.Mutex
+	lines  *[]string
+	fields []any
+}
+
+func newRecordLogger() *recordLogger {
+	return &recordLogger{mu: new(sync.Mutex), lines: new([]string)}
+}
+
+func (l *recordLogger) record(level, msg string, keysAndValues []any) {
+	var buf strings.Builder
+	buf.WriteString(level + " " + msg)
+	writeFields(&buf, appendFields(l.fields, keysAndValues))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	*l.lines = append(*l.lines, buf.String())
+}
+
+func (l *recordLogger) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), *l.lines...)
+}
+
+func (l *recordLogger) Debug(msg string, kv ...any) { l.record("DEBUG", msg, kv) }
+func (l *recordLogger) Info(msg string, kv ...any)  { l.record("INFO", msg, kv) }
+func (l *recordLogger) Warn(msg string, kv ...any)  { l.record("WARN", msg, kv) }
+func (l *recordLogger) Error(msg string, kv ...any) { l.record("ERROR", msg, kv) }
+
+func (l *recordLogger) With(kv ...any) Logger {
+	return &recordLogger{mu: l.mu, lines: l.lines, fields: appendFields(l.fields, kv)}
+}
+
+func TestNewLogger(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	logger := NewLogger(buffer, LevelInfo)
+
+	logger.Debug("hidden")
+	logger.Info("server started", "addr", ":8080")
+	child := logger.With("request_id", "req-1")
+	child.Warn("slow request", "latency", "1.5s", "path", "/users list")
+	logger.Error("failed", "error", fmt.Errorf("boom"), "odd")
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Regexp(t, `^time=\S+ level=INFO msg="server started" addr=:8080$`, lines[0])
+		assert.Regexp(t, `level=WARN msg="slow request" request_id=req-1 latency=1.5s path="/users list"$`, lines[1])
+		assert.Regexp(t, `level=ERROR msg=failed error=boom !BADKEY=odd$`, lines[2])
+	}
+
+	assert.Equal(t, "DEBUG", LevelDebug.String())
+	assert.Equal(t, "LEVEL(9)", LogLevel(9).String())
+}
+
+func TestDefaultLogger(t *testing.T) {
+	errBuffer := new(bytes.Buffer)
+	oldErrorWriter := DefaultErrorWriter
+	DefaultErrorWriter = errBuffer
+	defer func() { DefaultErrorWriter = oldErrorWriter }()
+
+	logger := DefaultLogger().With("route", "/users")
+	output := captureOutput(t, DebugMode, func() {
+		logger.Debug("debug message")
+		logger.Info("info message")
+		logger.Warn("warn message\n\n")
+		logger.Error("error message", "status", 500)
+	})
+	assert.Equal(t, "[FOX-debug] debug message route=/users\n[FOX] info message route=/users\n[FOX] [WARNING] warn message route=/users\n", output)
+	assert.Equal(t, "[ERROR] error message route=/users status=500\n", errBuffer.String())
+
+	output = captureOutput(t, ReleaseMode, func() {
+		logger.Debug("debug message")
+	})
+	assert.Empty(t, output)
+}
+
+func TestContextLogger(t *testing.T) {
+	logger := newRecordLogger()
+	router := New()
+	router.Logger = logger
+	router.Use(RequestID())
+	router.GET("/users/:id", func(c *Context) string {
+		c.Logger().Info("loading user", "id", c.Params.ByName("id"))
+		return "fox"
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, []string{"INFO loading user request_id=req-1 route=/users/:id client_ip=192.0.2.1 id=1"}, logger.Lines())
+}
+
+func TestEngineLoggerFrameworkMessages(t *testing.T) {
+	logger := newRecordLogger()
+	router := New()
+	router.Logger = logger
+	router.PanicHandler = func(w http.ResponseWriter, r *http.Request, rcv any) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	captureOutput(t, DebugMode, func() {
+		router.GET("/panic", func() { panic("oops") })
+		router.GET("/render", func() render.HTML {
+			return render.HTML{Template: template.Must(template.New("").Parse(`{{template "nope"}}`))}
+		})
+		router.GET("/template", func() render.HTML { return render.HTML{Name: "index"} })
+
+		PerformRequest(router, http.MethodGet, "/panic", nil)
+		PerformRequest(router, http.MethodGet, "/render", nil)
+		PerformRequest(router, http.MethodGet, "/template", nil)
+	})
+	assert.Error(t, router.RunUnix("/nonexistent/dir/fox.sock"))
+
+	lines := strings.Join(logger.Lines(), "\n")
+	assert.Contains(t, lines, "DEBUG GET    /panic")
+	assert.Contains(t, lines, `ERROR panic recovered route=/panic client_ip=192.0.2.1 error=oops stack=`)
+	assert.Contains(t, lines, `ERROR render failed route=/render client_ip=192.0.2.1 error="html/template::1:11: no such template`)
+	assert.Contains(t, lines, `WARN No HTML templates are loaded`)
+	assert.Contains(t, lines, `ERROR listen unix /nonexistent/dir/fox.sock`)
+
+	// the http.Server errors are written to the Logger as well
+	srv := router.newServer("", router)
+	srv.ErrorLog.Print("http: TLS handshake error")
+	assert.Contains(t, logger.Lines(), "ERROR http: TLS handshake error")
+}
@@ -5,6 +5,9 @@ import (
 	"os"
 )
 
+// EnvFoxMode indicates environment name for fox mode.
+const EnvFoxMode = "FOX_MODE"
+
 const (
 	// DebugMode indicates fox mode is debug.
 	DebugMode = "debug"
@@ -22,7 +25,11 @@ var DefaultErrorWriter io.Writer = os.Stderr
 
 var engineMode string
 
-// SetMode sets engine mode according to input string.
+func init() {
+	SetMode(os.Getenv(EnvFoxMode))
+}
+
+// SetMode sets engine mode according to input string, debug mode is used if it is empty.
 func SetMode(value string) {
 	switch value {
 	case DebugMode, ReleaseMode, TestMode:
@@ -32,3 +39,8 @@ func SetMode(value string) {
 		// panic("gin mode unknown: " + value + " (available mode: debug release test)")
 	}
 }
+
+// Mode returns current fox mode.
+func Mode() string {
+	return engineMode
+}
@@ -0,0 +1,296 @@
+package fox
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitAlgorithm is the algorithm of the RateLimit middleware.
+type RateLimitAlgorithm int
+
+const (
+	// RateLimitTokenBucket refills Limit tokens per Window into a bucket of Burst tokens,
+	// every request takes a token. It allows short bursts above the average rate.
+	RateLimitTokenBucket RateLimitAlgorithm = iota
+
+	// RateLimitSlidingWindow allows Limit requests in any Window, the count of the previous
+	// fixed window is weighted by its overlap with the sliding window.
+	RateLimitSlidingWindow
+)
+
+// RateLimitKeyFunc returns the key the requests are counted by, the request isn't limited
+// if the key is empty.
+type RateLimitKeyFunc func(c *Context) string
+
+// RateLimitConfig defines the config for the RateLimit middleware.
+type RateLimitConfig struct {
+	// Limit is the number of requests allowed per Window.
+	Limit int
+
+	// Window is the period of Limit, one minute by default.
+	Window time.Duration
+
+	// Burst is the size of the token bucket, Limit by default. It's ignored by
+	// RateLimitSlidingWindow.
+	Burst int
+
+	// Algorithm is RateLimitTokenBucket by default.
+	Algorithm RateLimitAlgorithm
+
+	// Key returns the key the requests are counted by, RateLimitByClientIP by default.
+	Key RateLimitKeyFunc
+
+	// Store keeps the state of the keys, a new NewMemoryRateLimitStore(0) by default.
+	// Share a store between the middlewares only if their keys don't collide.
+	Store RateLimitStore
+
+	// Optional. Skip reports whether the request isn't limited.
+	Skip func(c *Context) bool
+
+	// Optional. Handler is called instead of the default 429 Too Many Requests response
+	// when the limit is exceeded, the RateLimit headers are set before.
+	Handler func(c *Context)
+}
+
+// RateLimitResult is the result of a request taken by the rate limiter.
+type RateLimitResult struct {
+	// Allowed reports whether the request is allowed.
+	Allowed bool
+	// Limit is the number of requests allowed per window.
+	Limit int
+	// Remaining is the number of requests still allowed in the current window.
+	Remaining int
+	// Reset is the time until the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, 0 if it's allowed.
+	RetryAfter time.Duration
+}
+
+var default429Body = []byte("429 too many requests")
+
+// RateLimit returns a middleware which limits the rate of the requests of every key, the
+// client IP by default. The responses have the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers, and the requests beyond the limit are answered with
+// 429 Too Many Requests and a Retry-After header.
+//
+//	// 100 requests per minute per client, 10 login attempts per minute per user name
+//	router.Use(fox.RateLimit(fox.RateLimitConfig{Limit: 100}))
+//	router.POST("/login", fox.RateLimit(fox.RateLimitConfig{
+//		Limit:     10,
+//		Algorithm: fox.RateLimitSlidingWindow,
+//		Key:       fox.RateLimitByHeader("X-User"),
+//	}), login)
+func RateLimit(config RateLimitConfig) HandlerFunc {
+	if config.Limit <= 0 {
+		panic("rate limit must be greater than 0")
+	}
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.Burst <= 0 {
+		config.Burst = config.Limit
+	}
+	if config.Key == nil {
+		config.Key = RateLimitByClientIP
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore(0)
+	}
+
+	limiter := newRateLimiter(config)
+
+	return func(c *Context) {
+		if config.Skip != nil && config.Skip(c) {
+			return
+		}
+
+		key := config.Key(c)
+		if key == "" {
+			return
+		}
+
+		result, err := limiter.take(key, time.Now())
+		if err != nil {
+			c.Logger().Error("rate limit failed", "error", err)
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if result.Allowed {
+			return
+		}
+
+		header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		if config.Handler != nil {
+			config.Handler(c)
+			c.Abort()
+			return
+		}
+
+		c.Writer.Header()["Content-Type"] = mimePlain
+		c.AbortWithStatus(http.StatusTooManyRequests)
+		c.Writer.Write(default429Body)
+	}
+}
+
+// RateLimitByClientIP counts the requests by Context.ClientIP.
+func RateLimitByClientIP(c *Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitByRoute counts the requests by the matched route, all clients share the limit.
+func RateLimitByRoute(c *Context) string {
+	return "route:" + c.Request.Method + " " + c.FullPath()
+}
+
+// RateLimitByHeader counts the requests by the value of the header, e.g. an API key.
+// The requests without the header aren't limited.
+func RateLimitByHeader(name string) RateLimitKeyFunc {
+	return func(c *Context) string {
+		if value := c.Request.Header.Get(name); value != "" {
+			return "header:" + value
+		}
+		return ""
+	}
+}
+
+// RateLimitByUser counts the requests by the authenticated user stored under key by the
+// authentication middleware, see Context.Value. The anonymous requests are counted by
+// the client IP.
+func RateLimitByUser(key any) RateLimitKeyFunc {
+	return func(c *Context) string {
+		if user := c.Value(key); user != nil {
+			return "user:" + fmt.Sprint(user)
+		}
+		return RateLimitByClientIP(c)
+	}
+}
+
+// ceilSeconds returns d in seconds, rounded up.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+type rateLimiter struct {
+	config RateLimitConfig
+	ttl    time.Duration
+}
+
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	l := &rateLimiter{config: config}
+	switch config.Algorithm {
+	case RateLimitTokenBucket:
+		// the time to refill an empty bucket
+		l.ttl = config.Window * time.Duration(config.Burst) / time.Duration(config.Limit)
+	case RateLimitSlidingWindow:
+		// the previous window is still counted
+		l.ttl = 2 * config.Window
+	default:
+		panic(fmt.Sprintf("unknown rate limit algorithm %d", config.Algorithm))
+	}
+	return l
+}
+
+// take takes a request of key at now.
+func (l *rateLimiter) take(key string, now time.Time) (result RateLimitResult, err error) {
+	err = l.config.Store.Update(key, l.ttl, func(state any) any {
+		if l.config.Algorithm == RateLimitSlidingWindow {
+			s, _ := state.(*slidingWindow)
+			if s == nil {
+				s = &slidingWindow{start: now}
+			}
+			result = s.take(now, l.config.Limit, l.config.Window)
+			return s
+		}
+
+		b, _ := state.(*tokenBucket)
+		if b == nil {
+			b = &tokenBucket{tokens: float64(l.config.Burst), last: now}
+		}
+		result = b.take(now, l.config.Limit, l.config.Burst, l.config.Window)
+		return b
+	})
+	return result, err
+}
+
+// tokenBucket is the state of RateLimitTokenBucket.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) take(now time.Time, limit, burst int, window time.Duration) RateLimitResult {
+	// the interval between two tokens
+	interval := window / time.Duration(limit)
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+float64(elapsed)/float64(interval))
+		b.last = now
+	}
+
+	result := RateLimitResult{Limit: burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((float64(burst) - b.tokens) * float64(interval))
+	return result
+}
+
+// slidingWindow is the state of RateLimitSlidingWindow, the counts of the current and
+// the previous fixed windows.
+type slidingWindow struct {
+	start    time.Time
+	previous int
+	current  int
+}
+
+func (s *slidingWindow) take(now time.Time, limit int, window time.Duration) RateLimitResult {
+	// move to the window of now
+	if elapsed := now.Sub(s.start); elapsed >= window {
+		if elapsed < 2*window {
+			s.previous = s.current
+		} else {
+			s.previous = 0
+		}
+		s.current = 0
+		s.start = s.start.Add(elapsed / window * window)
+	}
+
+	elapsed := now.Sub(s.start)
+	weight := 1 - float64(elapsed)/float64(window)
+	count := float64(s.previous)*weight + float64(s.current)
+
+	result := RateLimitResult{Limit: limit, Reset: window - elapsed}
+	if count+1 <= float64(limit) {
+		s.current++
+		count++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = s.retryAfter(elapsed, limit, window)
+	}
+	result.Remaining = int(math.Max(0, float64(limit)-math.Ceil(count)))
+	return result
+}
+
+// retryAfter returns the time until the weighted count allows another request.
+func (s *slidingWindow) retryAfter(elapsed time.Duration, limit int, window time.Duration) time.Duration {
+	if s.current < limit && s.previous > 0 {
+		// previous * (window - elapsed - t) / window + current <= limit - 1
+		t := float64(window-elapsed) - float64(limit-1-s.current)*float64(window)/float64(s.previous)
+		return time.Duration(math.Max(0, t))
+	}
+
+	// wait for the next window, where the current count becomes the previous one
+	t := float64(window) * (1 - float64(limit-1)/float64(s.current))
+	return window - elapsed + time.Duration(math.Max(0, t))
+}
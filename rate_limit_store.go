@@ -0,0 +1,104 @@
+package fox
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// RateLimitStore keeps the state of the rate limiter of every key, implement it to share
+// the limits between the instances, e.g. with Redis.
+type RateLimitStore interface {
+	// Update calls fn with the state of key, nil if it's missing or expired, and stores
+	// the state fn returns for ttl. The updates of a key must be serialized.
+	Update(key string, ttl time.Duration, fn func(state any) any) error
+}
+
+// rateLimitShards is the number of the shards of the memory store.
+const rateLimitShards = 64
+
+// NewMemoryRateLimitStore returns a RateLimitStore which keeps the states in memory. The
+// keys are spread over shards locked separately, and the expired keys are evicted as the
+// shards are updated. If maxKeys > 0, the keys expiring first are evicted to keep at most
+// maxKeys keys.
+func NewMemoryRateLimitStore(maxKeys int) RateLimitStore {
+	s := &memoryRateLimitStore{}
+	if maxKeys > 0 {
+		s.maxShardKeys = (maxKeys + rateLimitShards - 1) / rateLimitShards
+	}
+	for i := range s.shards {
+		s.shards[i].entries = make(map[string]*rateLimitEntry)
+	}
+	return s
+}
+
+type memoryRateLimitStore struct {
+	shards       [rateLimitShards]rateLimitShard
+	maxShardKeys int
+}
+
+type rateLimitShard struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	nextSweep time.Time
+}
+
+type rateLimitEntry struct {
+	state   any
+	expires time.Time
+}
+
+func (s *memoryRateLimitStore) Update(key string, ttl time.Duration, fn func(state any) any) error {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	shard := &s.shards[h.Sum32()%rateLimitShards]
+
+	now := time.Now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if now.After(shard.nextSweep) {
+		shard.evictExpired(now)
+		shard.nextSweep = now.Add(ttl)
+	}
+
+	entry := shard.entries[key]
+	if entry == nil || now.After(entry.expires) {
+		if entry == nil && s.maxShardKeys > 0 && len(shard.entries) >= s.maxShardKeys {
+			shard.evictExpired(now)
+			if len(shard.entries) >= s.maxShardKeys {
+				shard.evictOldest()
+			}
+		}
+		entry = &rateLimitEntry{}
+		shard.entries[key] = entry
+	}
+
+	entry.state = fn(entry.state)
+	entry.expires = now.Add(ttl)
+	return nil
+}
+
+// evictExpired deletes the expired entries.
+func (shard *rateLimitShard) evictExpired(now time.Time) {
+	for key, entry := range shard.entries {
+		if now.After(entry.expires) {
+			delete(shard.entries, key)
+		}
+	}
+}
+
+// evictOldest deletes the entry expiring first.
+func (shard *rateLimitShard) evictOldest() {
+	var oldest *rateLimitEntry
+	var oldestKey string
+	for key, entry := range shard.entries {
+		if oldest == nil || entry.expires.Before(oldest.expires) {
+			oldest, oldestKey = entry, key
+		}
+	}
+	if oldest != nil {
+		delete(shard.entries, oldestKey)
+	}
+}
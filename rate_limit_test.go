@@ -0,0 +1,175 @@
+package fox
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	router := New()
+	router.Use(RateLimit(RateLimitConfig{Limit: 2, Window: time.Minute}))
+	router.GET("/", func(c *Context) string { return "ok" })
+
+	w := PerformRequest(router, http.MethodGet, "/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = PerformRequest(router, http.MethodGet, "/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = PerformRequest(router, http.MethodGet, "/", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "429 too many requests", w.Body.String())
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	type userKey struct{}
+
+	router := New()
+	router.GET("/header", RateLimit(RateLimitConfig{Limit: 1, Key: RateLimitByHeader("X-API-Key")}),
+		func(c *Context) string { return "ok" })
+	router.GET("/route/:id", RateLimit(RateLimitConfig{Limit: 1, Key: RateLimitByRoute}),
+		func(c *Context) string { return "ok" })
+	router.GET("/user",
+		func(c *Context) { c.Set("user", c.Request.Header.Get("X-User")) },
+		RateLimit(RateLimitConfig{Limit: 1, Key: RateLimitByUser("user"),
+			Handler: func(c *Context) { c.Writer.WriteHeader(http.StatusServiceUnavailable) }}),
+		func(c *Context) string { return "ok" })
+
+	keyA := http.Header{"X-Api-Key": []string{"a"}}
+	keyB := http.Header{"X-Api-Key": []string{"b"}}
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/header", keyA).Code)
+	assert.Equal(t, http.StatusTooManyRequests, PerformRequest(router, http.MethodGet, "/header", keyA).Code)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/header", keyB).Code)
+	w := PerformRequest(router, http.MethodGet, "/header", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/route/1", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, PerformRequest(router, http.MethodGet, "/route/2", nil).Code)
+
+	alice := http.Header{"X-User": []string{"alice"}}
+	bob := http.Header{"X-User": []string{"bob"}}
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/user", alice).Code)
+	w = PerformRequest(router, http.MethodGet, "/user", alice)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/user", bob).Code)
+}
+
+func TestRateLimitTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := &tokenBucket{tokens: 3, last: now}
+	limit, burst, window := 1, 3, time.Second
+
+	for i := 2; i >= 0; i-- {
+		result := b.take(now, limit, burst, window)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result := b.take(now, limit, burst, window)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	result = b.take(now.Add(500*time.Millisecond), limit, burst, window)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	result = b.take(now.Add(time.Second), limit, burst, window)
+	assert.True(t, result.Allowed)
+
+	// the bucket is refilled up to burst
+	result = b.take(now.Add(time.Hour), limit, burst, window)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestRateLimitSlidingWindow(t *testing.T) {
+	start := time.Now()
+	s := &slidingWindow{start: start}
+	limit, window := 4, 10*time.Second
+
+	for i := 3; i >= 0; i-- {
+		result := s.take(start, limit, window)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result := s.take(start.Add(5*time.Second), limit, window)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 5*time.Second, result.Reset)
+	// the next window weights the 4 requests by 1-t/10 <= 3/4
+	assert.Equal(t, 7500*time.Millisecond, result.RetryAfter)
+
+	// 4 * 0.5 weighted requests of the previous window
+	result = s.take(start.Add(15*time.Second), limit, window)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	result = s.take(start.Add(15*time.Second), limit, window)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	result = s.take(start.Add(15*time.Second), limit, window)
+	assert.False(t, result.Allowed)
+	// 4 * (10-5-t)/10 + 2 <= 3
+	assert.Equal(t, 2500*time.Millisecond, result.RetryAfter)
+
+	// the windows before are forgotten
+	result = s.take(start.Add(time.Minute), limit, window)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 3, result.Remaining)
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := NewMemoryRateLimitStore(rateLimitShards).(*memoryRateLimitStore)
+
+	incr := func(state any) any {
+		n, _ := state.(int)
+		return n + 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, store.Update("key", time.Minute, incr))
+		}()
+	}
+	wg.Wait()
+
+	var count int
+	assert.NoError(t, store.Update("key", time.Minute, func(state any) any {
+		count = state.(int)
+		return state
+	}))
+	assert.Equal(t, 100, count)
+
+	// expired states are reset
+	assert.NoError(t, store.Update("expired", -time.Second, incr))
+	assert.NoError(t, store.Update("expired", time.Minute, func(state any) any {
+		assert.Nil(t, state)
+		return state
+	}))
+
+	// at most maxKeys keys are kept
+	for i := 0; i < 10*rateLimitShards; i++ {
+		assert.NoError(t, store.Update(fmt.Sprint(i), time.Minute, incr))
+	}
+	for i := range store.shards {
+		assert.LessOrEqual(t, len(store.shards[i].entries), 1)
+	}
+}
@@ -0,0 +1,127 @@
+package fox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// HeaderXRequestID is the default header of the request ID.
+const HeaderXRequestID = "X-Request-ID"
+
+// defaultRequestIDMaxLength is the max length of an incoming request ID.
+const defaultRequestIDMaxLength = 128
+
+// contextKey is the type of the keys fox stores in the request context, so they
+// never collide with the keys of other packages.
+type contextKey string
+
+// RequestIDKey is the key of the request ID in the request context, set by the RequestID
+// middleware. The request context is passed to outgoing calls, see RequestIDFromContext.
+const RequestIDKey contextKey = "request_id"
+
+// RequestIDConfig defines the config for the RequestID middleware.
+type RequestIDConfig struct {
+	// Header is the header of the incoming and the echoed request ID, X-Request-ID by default.
+	Header string
+
+	// MaxLength is the max length of an incoming request ID, 128 by default.
+	// A longer ID is replaced by a generated one.
+	MaxLength int
+
+	// Generator returns a new request ID, a random UUID by default.
+	Generator func() string
+}
+
+// RequestID returns a middleware which tags the request with an ID. The ID sent by the
+// client in X-Request-ID is used if it's valid, otherwise a new ID is generated. The ID is
+// echoed in the response header, stored under RequestIDKey, and written by the access
+// log, Context.Logger and the error responses.
+func RequestID() HandlerFunc {
+	return RequestIDWithConfig(RequestIDConfig{})
+}
+
+// RequestIDWithConfig returns a RequestID middleware with config.
+func RequestIDWithConfig(config RequestIDConfig) HandlerFunc {
+	header := config.Header
+	if header == "" {
+		header = HeaderXRequestID
+	}
+	maxLength := config.MaxLength
+	if maxLength <= 0 {
+		maxLength = defaultRequestIDMaxLength
+	}
+	generator := config.Generator
+	if generator == nil {
+		generator = newRequestID
+	}
+
+	return func(c *Context) {
+		id := c.Request.Header.Get(header)
+		if !validRequestID(id, maxLength) {
+			id = generator()
+		}
+
+		c.Writer.Header().Set(header, id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), RequestIDKey, id))
+
+		// the logger created before is missing the ID
+		c.logger = nil
+	}
+}
+
+// RequestID returns the ID of the request set by the RequestID middleware, "" if the
+// middleware isn't used. The X-Request-ID sent by the client is never returned as is, it's
+// validated by the middleware before it reaches the logs.
+func (c *Context) RequestID() string {
+	return RequestIDFromContext(c.Request.Context())
+}
+
+// RequestIDFromContext returns the request ID stored in ctx by the RequestID middleware,
+// use it to propagate the ID to outgoing calls:
+//
+//	req, _ := http.NewRequestWithContext(c, http.MethodGet, url, nil)
+//	req.Header.Set(fox.HeaderXRequestID, fox.RequestIDFromContext(c))
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+// validRequestID reports whether id is a non-empty ID of at most maxLength letters, digits
+// and "-_.:+/=" characters, which is safe to be echoed in the headers and the logs.
+func validRequestID(id string, maxLength int) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch b := id[i]; {
+		case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		case b == '-', b == '_', b == '.', b == ':', b == '+', b == '/', b == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random (version 4) UUID.
+func newRequestID() string {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		panic(err)
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+	return string(buf[:])
+}
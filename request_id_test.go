@@ -0,0 +1,93 @@
+package fox
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	router := New()
+	router.Use(RequestID())
+
+	var ctxID, logID string
+	router.GET("/", func(c *Context) string {
+		ctxID = RequestIDFromContext(c)
+		logID = c.RequestID()
+		return "ok"
+	})
+	router.GET("/error", func(c *Context) error {
+		return errors.New("failed")
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/", nil)
+	id := w.Header().Get(HeaderXRequestID)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), id)
+	assert.Equal(t, id, ctxID)
+	assert.Equal(t, id, logID)
+
+	header := http.Header{"X-Request-Id": []string{"req-1"}}
+	w = PerformRequest(router, http.MethodGet, "/", header)
+	assert.Equal(t, "req-1", w.Header().Get(HeaderXRequestID))
+	assert.Equal(t, "req-1", ctxID)
+
+	for _, invalid := range []string{"req 1", "req\x00", "<script>", strings.Repeat("x", 129)} {
+		header = http.Header{"X-Request-Id": []string{invalid}}
+		w = PerformRequest(router, http.MethodGet, "/", header)
+		assert.NotEqual(t, invalid, w.Header().Get(HeaderXRequestID))
+		assert.Len(t, w.Header().Get(HeaderXRequestID), 36)
+	}
+
+	header = http.Header{"X-Request-Id": []string{"req-2"}}
+	w = PerformRequest(router, http.MethodGet, "/error", header)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "failed (request_id=req-2)", w.Body.String())
+}
+
+func TestRequestIDWithoutMiddleware(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	router := New()
+	router.Use(AccessLogWithWriter(buffer))
+	router.GET("/", func(c *Context) error {
+		assert.Empty(t, c.RequestID())
+		return errors.New("failed")
+	})
+
+	// the unvalidated ID of the client never reaches the logs and the responses
+	w := PerformRequest(router, http.MethodGet, "/", http.Header{"X-Request-Id": []string{"req\n1"}})
+	assert.Equal(t, "failed", w.Body.String())
+	assert.NotContains(t, buffer.String(), "req")
+}
+
+func TestRequestIDWithConfig(t *testing.T) {
+	var out bytes.Buffer
+	router := New()
+	router.Logger = NewLogger(&out, LevelInfo)
+	router.Use(RequestIDWithConfig(RequestIDConfig{
+		Header:    "X-Trace-ID",
+		MaxLength: 8,
+		Generator: func() string { return "generated" },
+	}))
+	router.GET("/", func(c *Context) string {
+		c.Logger().Info("handled")
+		return "ok"
+	})
+
+	header := http.Header{"X-Trace-Id": []string{"trace-1"}}
+	w := PerformRequest(router, http.MethodGet, "/", header)
+	assert.Equal(t, "trace-1", w.Header().Get("X-Trace-ID"))
+	assert.Empty(t, w.Header().Get(HeaderXRequestID))
+	assert.Contains(t, out.String(), "request_id=trace-1")
+
+	header = http.Header{"X-Trace-Id": []string{"trace-too-long"}}
+	w = PerformRequest(router, http.MethodGet, "/", header)
+	assert.Equal(t, "generated", w.Header().Get("X-Trace-ID"))
+
+	w = PerformRequest(router, http.MethodGet, "/", nil)
+	assert.Equal(t, "generated", w.Header().Get("X-Trace-ID"))
+}
@@ -22,12 +22,19 @@ type ResponseWriter struct {
 	http.ResponseWriter
 	size   int
 	status int
+
+	// logger writes the warnings, DefaultLogger is used if it is nil.
+	logger Logger
+
+	// beforeWrite are called once before the header is written, e.g. to set the session cookie.
+	beforeWrite []func()
 }
 
 func (w *ResponseWriter) reset(writer http.ResponseWriter) {
 	w.ResponseWriter = writer
 	w.size = noWritten
 	w.status = defaultStatus
+	w.beforeWrite = nil
 }
 
 // WriteHeader sends an HTTP response header with the provided
@@ -35,7 +42,7 @@ func (w *ResponseWriter) reset(writer http.ResponseWriter) {
 func (w *ResponseWriter) WriteHeader(statusCode int) {
 	if statusCode > 0 && w.status != statusCode {
 		if w.Written() {
-			// debugPrint("[WARNING] Headers were already written. Wanted to override status code %d with %d", w.status, code)
+			debugPrintWARNING(w.logger, "Headers were already written. Wanted to override status code %d with %d", w.status, statusCode)
 		}
 		w.status = statusCode
 	}
@@ -44,6 +51,11 @@ func (w *ResponseWriter) WriteHeader(statusCode int) {
 // WriteHeaderNow forces to write the http header (status code + headers).
 func (w *ResponseWriter) WriteHeaderNow() {
 	if !w.Written() {
+		hooks := w.beforeWrite
+		w.beforeWrite = nil
+		for _, hook := range hooks {
+			hook()
+		}
 		w.size = 0
 		w.ResponseWriter.WriteHeader(w.status)
 	}
@@ -86,7 +98,12 @@ func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if w.size < 0 {
 		w.size = 0
 	}
-	return w.ResponseWriter.(http.Hijacker).Hijack()
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		// HTTP/2 connections can't be hijacked
+		return nil, nil, http.ErrNotSupported
+	}
+	return hijacker.Hijack()
 }
 
 // CloseNotify implements the http.CloseNotifier interface.
@@ -97,7 +114,15 @@ func (w *ResponseWriter) CloseNotify() <-chan bool {
 // Flush implements the http.Flusher interface.
 func (w *ResponseWriter) Flush() {
 	w.WriteHeaderNow()
-	w.ResponseWriter.(http.Flusher).Flush()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter, so the code which unwraps the
+// writers can reach the features of the original writer, e.g. its connection deadlines.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
 
 // Pusher implements the http.Pusher interface.
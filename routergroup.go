@@ -23,14 +23,30 @@ type RouterGroup struct {
 	Handlers HandlersChain
 	basePath string
 	engine   *Engine
+	parent   *RouterGroup
 	root     bool
 }
 
 // Use adds middleware to the group, see example code in GitHub.
 func (group *RouterGroup) Use(middleware ...HandlerFunc) {
+	if group.hasRoutes() {
+		debugPrintWARNINGUse(group.engine.Logger)
+	}
 	group.Handlers = append(group.Handlers, middleware...)
 }
 
+// hasRoutes reports whether routes are registered through the group or its subgroups.
+func (group *RouterGroup) hasRoutes() bool {
+	for _, owner := range group.engine.routeGroups {
+		for ; owner != nil; owner = owner.parent {
+			if owner == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Group creates a new router group. You should add all the routes that have common middlewares or the same path prefix.
 // For example, all the routes that use a common middleware for authorization could be grouped.
 func (group *RouterGroup) Group(relativePath string, handlers ...HandlerFunc) *RouterGroup {
@@ -38,13 +54,22 @@ func (group *RouterGroup) Group(relativePath string, handlers ...HandlerFunc) *R
 		Handlers: append(group.Handlers, handlers...),
 		basePath: group.calculateAbsolutePath(relativePath),
 		engine:   group.engine,
+		parent:   group,
 	}
 }
 
 func (group *RouterGroup) handle(method, relativePath string, handlers HandlersChain) {
 	absolutePath := group.calculateAbsolutePath(relativePath)
 	handlers = append(group.Handlers, handlers...)
+	if len(handlers) >= abortIndex {
+		panic("too many handlers")
+	}
 	group.engine.addRoute(method, absolutePath, handlers)
+
+	if group.engine.routeGroups == nil {
+		group.engine.routeGroups = make(map[string]*RouterGroup)
+	}
+	group.engine.routeGroups[method+" "+absolutePath] = group
 }
 
 // Handle registers a new request handle with the given path and method.
@@ -0,0 +1,232 @@
+package fox
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cspNoncePlaceholder is replaced by the nonce of the request in ContentSecurityPolicy.
+const cspNoncePlaceholder = "{nonce}"
+
+// SecureConfig defines the config for the Secure middleware, the headers with a zero
+// value aren't set.
+type SecureConfig struct {
+	// AllowedHosts are the hosts of the requests, e.g. "example.com", "example.com:8080" or
+	// "*.example.com". The requests of the other hosts are answered 400 Bad Request. All
+	// the hosts are allowed if it's empty.
+	AllowedHosts []string
+
+	// SSLRedirect redirects the HTTP requests to HTTPS, with 301 Moved Permanently for GET
+	// and HEAD, and 308 Permanent Redirect for the other methods.
+	SSLRedirect bool
+
+	// SSLHost is the host of the redirects, the host of the request by default.
+	SSLHost string
+
+	// SSLProxyHeaders are the headers and their values of the HTTPS requests forwarded by the
+	// proxies, X-Forwarded-Proto: https by default. They are used only if the remote
+	// address is a trusted proxy, see Engine.SetTrustedProxies.
+	SSLProxyHeaders map[string]string
+
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header, sent on the HTTPS
+	// requests only.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+
+	// ContentTypeNosniff sets X-Content-Type-Options: nosniff.
+	ContentTypeNosniff bool
+
+	// FrameOptions is the X-Frame-Options header, e.g. "DENY" or "SAMEORIGIN".
+	FrameOptions string
+
+	// ReferrerPolicy is the Referrer-Policy header, e.g. "strict-origin-when-cross-origin".
+	ReferrerPolicy string
+
+	// PermissionsPolicy is the Permissions-Policy header, e.g. "camera=(), microphone=()".
+	PermissionsPolicy string
+
+	// CrossOriginOpenerPolicy is the Cross-Origin-Opener-Policy header, e.g. "same-origin".
+	CrossOriginOpenerPolicy string
+
+	// CrossOriginEmbedderPolicy is the Cross-Origin-Embedder-Policy header, e.g. "require-corp".
+	CrossOriginEmbedderPolicy string
+
+	// ContentSecurityPolicy is the Content-Security-Policy header, "{nonce}" is replaced by a
+	// random nonce per request, e.g. "script-src 'self' 'nonce-{nonce}'". The nonce is
+	// returned by Context.CSPNonce and the cspNonce func of the templates.
+	ContentSecurityPolicy string
+
+	// ContentSecurityPolicyReportOnly sends the policy in Content-Security-Policy-Report-Only,
+	// so the violations are reported but not blocked.
+	ContentSecurityPolicyReportOnly bool
+
+	// Optional. Skip reports whether the request is passed as is.
+	Skip func(c *Context) bool
+}
+
+// DefaultSecureConfig returns the recommended config of the Secure middleware, a year of
+// HSTS, nosniff, DENY framing, strict-origin-when-cross-origin referrers, same-origin
+// opener and a CSP allowing the same origin and the scripts with the nonce.
+func DefaultSecureConfig() SecureConfig {
+	return SecureConfig{
+		HSTSMaxAge:              365 * 24 * time.Hour,
+		HSTSIncludeSubdomains:   true,
+		ContentTypeNosniff:      true,
+		FrameOptions:            "DENY",
+		ReferrerPolicy:          "strict-origin-when-cross-origin",
+		CrossOriginOpenerPolicy: "same-origin",
+		ContentSecurityPolicy: "default-src 'self'; script-src 'self' 'nonce-{nonce}'; " +
+			"object-src 'none'; base-uri 'self'; frame-ancestors 'none'",
+	}
+}
+
+// Secure returns a middleware which sets the security headers of DefaultSecureConfig.
+// The scripts of the templates must carry the nonce:
+//
+//	<script nonce="{{ cspNonce }}">...</script>
+func Secure() HandlerFunc {
+	return SecureWithConfig(DefaultSecureConfig())
+}
+
+// SecureWithConfig returns a Secure middleware with config.
+func SecureWithConfig(config SecureConfig) HandlerFunc {
+	proxyHeaders := config.SSLProxyHeaders
+	if proxyHeaders == nil {
+		proxyHeaders = map[string]string{"X-Forwarded-Proto": "https"}
+	}
+
+	var hsts string
+	if config.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(config.HSTSMaxAge/time.Second), 10)
+		if config.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if config.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	cspHeader := "Content-Security-Policy"
+	if config.ContentSecurityPolicyReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	cspNonce := strings.Contains(config.ContentSecurityPolicy, cspNoncePlaceholder)
+
+	headers := make(http.Header)
+	if config.ContentTypeNosniff {
+		headers.Set("X-Content-Type-Options", "nosniff")
+	}
+	for name, value := range map[string]string{
+		"X-Frame-Options":              config.FrameOptions,
+		"Referrer-Policy":              config.ReferrerPolicy,
+		"Permissions-Policy":           config.PermissionsPolicy,
+		"Cross-Origin-Opener-Policy":   config.CrossOriginOpenerPolicy,
+		"Cross-Origin-Embedder-Policy": config.CrossOriginEmbedderPolicy,
+	} {
+		if value != "" {
+			headers.Set(name, value)
+		}
+	}
+
+	return func(c *Context) {
+		if config.Skip != nil && config.Skip(c) {
+			return
+		}
+
+		if len(config.AllowedHosts) > 0 && !allowedHost(config.AllowedHosts, c.Request.Host) {
+			c.Writer.Header()["Content-Type"] = mimePlain
+			c.AbortWithStatus(http.StatusBadRequest)
+			c.Writer.WriteString("400 bad request")
+			return
+		}
+
+		https := isHTTPS(c, proxyHeaders)
+		if config.SSLRedirect && !https {
+			host := config.SSLHost
+			if host == "" {
+				host = c.Request.Host
+			}
+			code := http.StatusPermanentRedirect
+			if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+				code = http.StatusMovedPermanently
+			}
+			http.Redirect(c.Writer, c.Request, "https://"+host+c.Request.URL.RequestURI(), code)
+			c.Abort()
+			return
+		}
+
+		header := c.Writer.Header()
+		for name, values := range headers {
+			header[name] = values
+		}
+		if hsts != "" && https {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+
+		if config.ContentSecurityPolicy != "" {
+			csp := config.ContentSecurityPolicy
+			if cspNonce {
+				c.cspNonce = newCSPNonce()
+				c.setTemplateFunc("cspNonce", c.CSPNonce)
+				csp = strings.ReplaceAll(csp, cspNoncePlaceholder, c.cspNonce)
+			}
+			header.Set(cspHeader, csp)
+		}
+	}
+}
+
+// CSPNonce returns the Content-Security-Policy nonce of the request, "" if the Secure
+// middleware isn't used or its policy has no nonce.
+func (c *Context) CSPNonce() string {
+	return c.cspNonce
+}
+
+// newCSPNonce returns a random nonce of 128 bits.
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// isHTTPS reports whether the request is HTTPS, or forwarded from HTTPS by a trusted proxy.
+func isHTTPS(c *Context, proxyHeaders map[string]string) bool {
+	if c.Request.TLS != nil {
+		return true
+	}
+	if len(proxyHeaders) == 0 || !c.engine.isTrustedProxy(net.ParseIP(c.RemoteIP())) {
+		return false
+	}
+	for name, value := range proxyHeaders {
+		if strings.EqualFold(c.Request.Header.Get(name), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedHost reports whether host, with or without the port, is one of the allowed hosts.
+func allowedHost(allowed []string, host string) bool {
+	host = strings.ToLower(host)
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == host || pattern == hostname {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(hostname, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package fox
+
+import (
+	"crypto/tls"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/miclle/fox/render"
+)
+
+func TestSecure(t *testing.T) {
+	router := New()
+	router.SetHTMLTemplate(template.Must(template.New("page").Funcs(router.FuncMap).Parse(
+		`<script nonce="{{ cspNonce }}"></script>`)))
+	router.Use(Secure())
+	router.GET("/", func(c *Context) render.HTML {
+		return render.HTML{Name: "page"}
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", w.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "same-origin", w.Header().Get("Cross-Origin-Opener-Policy"))
+	assert.Empty(t, w.Header().Get("Cross-Origin-Embedder-Policy"))
+	// HSTS is sent on HTTPS only
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+
+	matches := regexp.MustCompile(`<script nonce="([^"]+)">`).FindStringSubmatch(w.Body.String())
+	assert.Len(t, matches, 2, w.Body.String())
+	nonce := matches[1]
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "script-src 'self' 'nonce-"+nonce+"'")
+
+	w = PerformRequest(router, http.MethodGet, "/", nil)
+	assert.NotContains(t, w.Body.String(), nonce)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+}
+
+func TestSecureWithConfig(t *testing.T) {
+	router := New()
+	router.Use(SecureWithConfig(SecureConfig{
+		PermissionsPolicy:               "camera=()",
+		CrossOriginEmbedderPolicy:       "require-corp",
+		ContentSecurityPolicy:           "default-src 'self'",
+		ContentSecurityPolicyReportOnly: true,
+	}))
+	router.GET("/", func(c *Context) string {
+		assert.Empty(t, c.CSPNonce())
+		return "ok"
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/", nil)
+	assert.Equal(t, "camera=()", w.Header().Get("Permissions-Policy"))
+	assert.Equal(t, "require-corp", w.Header().Get("Cross-Origin-Embedder-Policy"))
+	assert.Equal(t, "default-src 'self'", w.Header().Get("Content-Security-Policy-Report-Only"))
+	assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+	assert.Empty(t, w.Header().Get("X-Frame-Options"))
+}
+
+func TestSecureSSLRedirect(t *testing.T) {
+	router := New()
+	router.Use(SecureWithConfig(SecureConfig{SSLRedirect: true, HSTSMaxAge: time.Hour, HSTSPreload: true}))
+	router.GET("/path", func(c *Context) string { return "ok" })
+	router.POST("/path", func(c *Context) string { return "ok" })
+
+	perform := func(method, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://example.com/path?q=1", nil)
+		req.RemoteAddr = remoteAddr
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := perform(http.MethodGet, "10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "https://example.com/path?q=1", w.Header().Get("Location"))
+
+	w = perform(http.MethodPost, "10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+
+	// the proxy headers of untrusted clients are ignored
+	header := http.Header{"X-Forwarded-Proto": []string{"https"}}
+	w = perform(http.MethodGet, "10.0.0.1:1234", header)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+
+	assert.NoError(t, router.SetTrustedProxies([]string{"10.0.0.0/8"}))
+	w = perform(http.MethodGet, "10.0.0.1:1234", header)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "max-age=3600; preload", w.Header().Get("Strict-Transport-Security"))
+
+	w = perform(http.MethodGet, "192.0.2.1:1234", header)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+}
+
+func TestSecureAllowedHosts(t *testing.T) {
+	router := New()
+	router.Use(SecureWithConfig(SecureConfig{AllowedHosts: []string{"example.com", "*.example.org"}}))
+	router.GET("/", func(c *Context) string { return "ok" })
+
+	for host, code := range map[string]int{
+		"example.com":       http.StatusOK,
+		"EXAMPLE.com:8080":  http.StatusOK,
+		"api.example.org":   http.StatusOK,
+		"example.org":       http.StatusBadRequest,
+		"evil.com":          http.StatusBadRequest,
+		"example.com.evil":  http.StatusBadRequest,
+		"api.example.org.x": http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, host)
+	}
+}
@@ -0,0 +1,593 @@
+package fox
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// ServerConfig configures the http.Server created by the Run methods, see http.Server
+// for the meaning of the fields. Zero durations mean no timeout.
+type ServerConfig struct {
+	// ReadTimeout is the maximum duration for reading the entire request, including the body.
+	ReadTimeout time.Duration
+
+	// ReadHeaderTimeout is the amount of time allowed to read request headers,
+	// it defaults to 10s which stops slowloris clients holding connections open.
+	ReadHeaderTimeout time.Duration
+
+	// WriteTimeout is the maximum duration before timing out writes of the response,
+	// keep it zero for long-lived streaming responses.
+	WriteTimeout time.Duration
+
+	// IdleTimeout is the maximum amount of time to wait for the next request when
+	// keep-alives are enabled, it defaults to 120s.
+	IdleTimeout time.Duration
+
+	// MaxHeaderBytes controls the maximum number of bytes the server will read parsing
+	// the request header's keys and values, including the request line.
+	MaxHeaderBytes int
+
+	// ErrorLog specifies an optional logger for errors accepting connections,
+	// unexpected behavior from handlers, and underlying FileSystem errors.
+	// The errors are written to Engine.Logger if it is nil.
+	ErrorLog *log.Logger
+
+	// ConnState specifies an optional callback function that is called when a
+	// client connection changes state, e.g. to count the open connections.
+	ConnState func(net.Conn, http.ConnState)
+}
+
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+)
+
+func defaultServerConfig() ServerConfig {
+	return ServerConfig{
+		ReadHeaderTimeout: defaultReadHeaderTimeout,
+		IdleTimeout:       defaultIdleTimeout,
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+	}
+}
+
+// newServer returns a http.Server of engine.Server serving addr with handler.
+func (engine *Engine) newServer(addr string, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       engine.Server.ReadTimeout,
+		ReadHeaderTimeout: engine.Server.ReadHeaderTimeout,
+		WriteTimeout:      engine.Server.WriteTimeout,
+		IdleTimeout:       engine.Server.IdleTimeout,
+		MaxHeaderBytes:    engine.Server.MaxHeaderBytes,
+		ErrorLog:          engine.Server.ErrorLog,
+		ConnState:         engine.Server.ConnState,
+	}
+	if srv.ErrorLog == nil {
+		srv.ErrorLog = log.New(logWriter{engine.logger()}, "", 0)
+	}
+	return srv
+}
+
+// RunContext is like Run, but shuts down gracefully when ctx is done: it stops accepting
+// connections, drains in-flight requests up to engine.ShutdownTimeout and runs the
+// shutdown hooks. It returns nil once the engine is shut down.
+func (engine *Engine) RunContext(ctx context.Context, addr string) (err error) {
+	if err = engine.start(ctx); err != nil {
+		return
+	}
+	defer engine.abort(&err)
+
+	debugPrint(engine.Logger, "Listening and serving HTTP on %s\n", addr)
+	srv, err := engine.newCleartextServer(addr, engine)
+	if err != nil {
+		engine.logger().Error(err.Error())
+		return
+	}
+	return engine.serve(ctx, srv, srv.ListenAndServe)
+}
+
+// newCleartextServer is like newServer, the server accepts h2c if engine.H2C is enabled.
+func (engine *Engine) newCleartextServer(addr string, handler http.Handler) (*http.Server, error) {
+	srv := engine.newServer(addr, handler)
+	if !engine.H2C {
+		return srv, nil
+	}
+
+	// ConfigureServer registers the HTTP/2 connections on srv, so Shutdown sends
+	// them GOAWAY as well
+	h2s := &http2.Server{}
+	if err := http2.ConfigureServer(srv, h2s); err != nil {
+		return nil, err
+	}
+	srv.Handler = h2c.NewHandler(handler, h2s)
+	return srv, nil
+}
+
+// RunTLS attaches the router to a http.Server and starts listening and serving HTTPS
+// requests with the certificate and key files, the TLS config defaults to TLS 1.2 and
+// above with forward secret AEAD cipher suites.
+// Note: this method will block the calling goroutine indefinitely unless an error happens.
+func (engine *Engine) RunTLS(addr, certFile, keyFile string) (err error) {
+	if err = engine.start(context.Background()); err != nil {
+		return
+	}
+	defer engine.abort(&err)
+
+	debugPrint(engine.Logger, "Listening and serving HTTPS on %s\n", addr)
+	srv := engine.newServer(addr, engine)
+	srv.TLSConfig = defaultTLSConfig()
+	return engine.serve(context.Background(), srv, func() error {
+		return srv.ListenAndServeTLS(certFile, keyFile)
+	})
+}
+
+// RunUnix attaches the router to a http.Server and starts listening and serving HTTP
+// requests through the unix socket file. A stale socket file left by a crashed process
+// is removed, the socket file is chmod to engine.UnixSocketMode if it is set.
+// Note: this method will block the calling goroutine indefinitely unless an error happens.
+func (engine *Engine) RunUnix(file string) (err error) {
+	if err = engine.start(context.Background()); err != nil {
+		return
+	}
+	defer engine.abort(&err)
+
+	debugPrint(engine.Logger, "Listening and serving HTTP on unix:/%s", file)
+	listener, err := listenUnix(file, engine.UnixSocketMode)
+	if err != nil {
+		engine.logger().Error(err.Error())
+		return
+	}
+	return engine.runListener(listener)
+}
+
+// RunFd attaches the router to a http.Server and starts listening and serving HTTP
+// requests through the file descriptor, e.g. a socket passed by systemd, see ListenFds.
+// Note: this method will block the calling goroutine indefinitely unless an error happens.
+func (engine *Engine) RunFd(fd int) (err error) {
+	if err = engine.start(context.Background()); err != nil {
+		return
+	}
+	defer engine.abort(&err)
+
+	debugPrint(engine.Logger, "Listening and serving HTTP on fd@%d", fd)
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd@%d", fd))
+	if f == nil {
+		err = fmt.Errorf("invalid file descriptor %d", fd)
+		engine.logger().Error(err.Error())
+		return
+	}
+	defer f.Close()
+
+	listener, err := net.FileListener(f)
+	if err != nil {
+		engine.logger().Error(err.Error())
+		return
+	}
+	return engine.runListener(listener)
+}
+
+// RunListener attaches the router to a http.Server and starts listening and serving HTTP
+// requests through the listener.
+// Note: this method will block the calling goroutine indefinitely unless an error happens.
+func (engine *Engine) RunListener(listener net.Listener) (err error) {
+	if err = engine.start(context.Background()); err != nil {
+		return
+	}
+	defer engine.abort(&err)
+
+	debugPrint(engine.Logger, "Listening and serving HTTP on listener %s", listener.Addr())
+	return engine.runListener(listener)
+}
+
+func (engine *Engine) runListener(listener net.Listener) error {
+	srv, err := engine.newCleartextServer("", engine)
+	if err != nil {
+		engine.logger().Error(err.Error())
+		return err
+	}
+	return engine.serve(context.Background(), srv, func() error {
+		return srv.Serve(listener)
+	})
+}
+
+// Endpoint is a listener served by RunListeners.
+type Endpoint struct {
+	// Addr is the TCP address to listen on, ignored if Listener is set.
+	Addr string
+
+	// Listener accepts the connections of the endpoint, e.g. a unix socket.
+	Listener net.Listener
+
+	// If set, the endpoint serves only the routes registered through Group and its
+	// subgroups, and these routes are not served by the endpoints without group.
+	Group *RouterGroup
+}
+
+// RunListeners serves all the endpoints at once until ctx is done or one of them fails,
+// then shuts down the engine gracefully like RunContext. For example a public port plus
+// an internal admin port:
+//
+//	admin := router.Group("/admin")
+//	router.RunListeners(ctx, fox.Endpoint{Addr: ":8080"}, fox.Endpoint{Addr: "127.0.0.1:9090", Group: admin})
+func (engine *Engine) RunListeners(ctx context.Context, endpoints ...Endpoint) (err error) {
+	if len(endpoints) == 0 {
+		return errors.New("no endpoints to serve")
+	}
+
+	if err = engine.start(ctx); err != nil {
+		return
+	}
+	defer engine.abort(&err)
+
+	listeners := make([]net.Listener, len(endpoints))
+	for i, endpoint := range endpoints {
+		if listeners[i] = endpoint.Listener; listeners[i] != nil {
+			continue
+		}
+
+		addr := endpoint.Addr
+		if addr == "" {
+			addr = ":http"
+		}
+		if listeners[i], err = net.Listen("tcp", addr); err != nil {
+			for _, l := range listeners[:i] {
+				l.Close()
+			}
+			engine.logger().Error(err.Error())
+			return
+		}
+	}
+
+	for i, endpoint := range endpoints {
+		if endpoint.Group != nil {
+			debugPrint(engine.Logger, "Listening and serving HTTP on %s for routes of %s", listeners[i].Addr(), endpoint.Group.basePath)
+		} else {
+			debugPrint(engine.Logger, "Listening and serving HTTP on %s", listeners[i].Addr())
+		}
+	}
+
+	// bind all the groups before serving, so no endpoint serves the routes of another one,
+	// the routes are served by ServeHTTP and the other Run methods again once it returns
+	groups := make([]*RouterGroup, len(endpoints))
+	for i, endpoint := range endpoints {
+		if endpoint.Group != &engine.RouterGroup {
+			groups[i] = endpoint.Group
+		}
+	}
+	defer engine.bindGroups(groups...)()
+
+	servers := make([]*http.Server, 0, len(endpoints))
+	for i := range endpoints {
+		group := groups[i]
+
+		srv, err := engine.newCleartextServer(listeners[i].Addr().String(), http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			engine.serveHTTP(w, req, group)
+		}))
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			for _, srv := range servers {
+				engine.trackServer(srv, false)
+			}
+			engine.logger().Error(err.Error())
+			return err
+		}
+
+		// track the servers before serving, so an early Shutdown closes all of them
+		engine.trackServer(srv, true)
+		servers = append(servers, srv)
+	}
+
+	errCh := make(chan error, len(servers))
+	for i := range servers {
+		srv, listener := servers[i], listeners[i]
+		go func() {
+			errCh <- engine.serve(context.Background(), srv, func() error {
+				return srv.Serve(listener)
+			})
+		}()
+	}
+
+	remaining := len(servers)
+	select {
+	case <-ctx.Done():
+	case err = <-errCh:
+		remaining--
+	}
+
+	// shut down the others if ctx is done or a server failed, nil means the engine
+	// was shut down already
+	if ctx.Err() != nil || err != nil {
+		shutdownCtx, cancel := engine.shutdownContext()
+		defer cancel()
+
+		if shutdownErr := engine.Shutdown(shutdownCtx); err == nil {
+			err = shutdownErr
+		}
+	}
+
+	for ; remaining > 0; remaining-- {
+		if serveErr := <-errCh; err == nil {
+			err = serveErr
+		}
+	}
+	return
+}
+
+// OnStart registers hooks which are run by the Run methods before listening, e.g. to
+// start background workers. The hooks are run in order, the first error aborts the
+// startup and is returned by the Run method. If a start hook, the listening or the
+// serving fails, the shutdown hooks are run to release what the start hooks acquired,
+// so the shutdown hooks must tolerate the start hooks which didn't run.
+func (engine *Engine) OnStart(hooks ...func(ctx context.Context) error) {
+	engine.mu.Lock()
+	engine.startHooks = append(engine.startHooks, hooks...)
+	engine.mu.Unlock()
+}
+
+// start runs the start hooks with ctx of the Run method, the shutdown hooks are run if
+// one of them fails.
+func (engine *Engine) start(ctx context.Context) (err error) {
+	engine.mu.Lock()
+	hooks := engine.startHooks
+	engine.stopped = false
+	engine.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err = hook(ctx); err != nil {
+			engine.logger().Error(err.Error())
+			engine.abort(&err)
+			return
+		}
+	}
+	return
+}
+
+// abort runs the shutdown hooks if *err is not nil and they haven't been run by Shutdown,
+// so the resources acquired by the start hooks are released when the startup fails.
+func (engine *Engine) abort(err *error) {
+	if *err == nil {
+		return
+	}
+
+	engine.mu.Lock()
+	stopped := engine.stopped
+	engine.stopped = true
+	hooks := engine.shutdownHooks
+	engine.mu.Unlock()
+
+	if stopped {
+		return
+	}
+
+	ctx, cancel := engine.shutdownContext()
+	defer cancel()
+
+	for _, hook := range hooks {
+		if hookErr := hook(ctx); hookErr != nil {
+			engine.logger().Error(hookErr.Error())
+		}
+	}
+}
+
+// OnShutdown registers hooks which are run by Shutdown while in-flight requests are
+// draining, e.g. to stop background workers. The hooks are run in order, they are run
+// by the Run methods as well if the startup fails, see OnStart.
+func (engine *Engine) OnShutdown(hooks ...func(ctx context.Context) error) {
+	engine.mu.Lock()
+	engine.shutdownHooks = append(engine.shutdownHooks, hooks...)
+	engine.mu.Unlock()
+}
+
+// Shutdown gracefully shuts down all the servers started by the Run methods without
+// interrupting any active connections, and runs the shutdown hooks. If ctx expires
+// before the requests are drained, Shutdown returns the context's error.
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	engine.draining.Add(1)
+	defer engine.draining.Done()
+
+	engine.mu.Lock()
+	servers := make([]*http.Server, 0, len(engine.servers))
+	for srv := range engine.servers {
+		servers = append(servers, srv)
+	}
+	hooks := engine.shutdownHooks
+	engine.stopped = true
+	engine.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, len(servers)+len(hooks))
+	)
+
+	for _, srv := range servers {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			errs <- srv.Shutdown(ctx)
+		}(srv)
+	}
+
+	for _, hook := range hooks {
+		errs <- hook(ctx)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serve tracks srv and runs serve until it fails or ctx is done, then shuts down the engine.
+func (engine *Engine) serve(ctx context.Context, srv *http.Server, serve func() error) (err error) {
+	defer func() {
+		if err != nil {
+			engine.logger().Error(err.Error())
+		}
+	}()
+
+	// Shutdown missed srv if it ran before srv is tracked, close srv so serve returns
+	// http.ErrServerClosed at once, the listener is closed as well
+	if engine.trackServer(srv, true) {
+		srv.Close()
+	}
+	defer engine.trackServer(srv, false)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serve()
+	}()
+
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		shutdownCtx, cancel := engine.shutdownContext()
+		defer cancel()
+
+		err = engine.Shutdown(shutdownCtx)
+		if serveErr := <-errCh; err == nil {
+			err = serveErr
+		}
+	}
+
+	// the server is closed by Shutdown, wait until the requests are drained
+	if errors.Is(err, http.ErrServerClosed) {
+		engine.draining.Wait()
+		err = nil
+	}
+	return
+}
+
+// shutdownContext returns the context of Shutdown with engine.ShutdownTimeout.
+func (engine *Engine) shutdownContext() (context.Context, context.CancelFunc) {
+	if engine.ShutdownTimeout > 0 {
+		return context.WithTimeout(context.Background(), engine.ShutdownTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// trackServer adds or removes srv of the servers shut down by Shutdown, it reports whether
+// the engine is shut down already.
+func (engine *Engine) trackServer(srv *http.Server, add bool) (stopped bool) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	if engine.servers == nil {
+		engine.servers = make(map[*http.Server]struct{})
+	}
+	if add {
+		engine.servers[srv] = struct{}{}
+	} else {
+		delete(engine.servers, srv)
+	}
+	return engine.stopped
+}
+
+// defaultTLSConfig returns the TLS config of RunTLS.
+func defaultTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+		},
+	}
+}
+
+// listenUnix listens on the unix socket file, a stale socket file is removed first.
+func listenUnix(file string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(file); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a unix socket", file)
+		}
+
+		// the socket is in use if someone accepts the connection
+		if conn, err := net.Dial("unix", file); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("unix socket %s is already in use", file)
+		}
+
+		if err := os.Remove(file); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("unix", file)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != 0 {
+		if err := os.Chmod(file, mode); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return listener, nil
+}
+
+// listenFdsStart is the first file descriptor passed by systemd socket activation.
+const listenFdsStart = 3
+
+// ListenFds returns the file descriptors passed by systemd socket activation, which
+// are described by the LISTEN_PID and LISTEN_FDS environment variables, or nil if
+// the process is not socket activated.
+//
+//	if fds := fox.ListenFds(); len(fds) > 0 {
+//		router.RunFd(fds[0])
+//	}
+func ListenFds() []int {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil
+	}
+
+	fds := make([]int, n)
+	for i := range fds {
+		fds[i] = listenFdsStart + i
+	}
+	return fds
+}
+
+// SignalContext returns a copy of parent which is done when one of the signals arrives,
+// SIGINT and SIGTERM by default. It wires the signals into RunContext:
+//
+//	ctx, stop := fox.SignalContext(context.Background())
+//	defer stop()
+//	router.RunContext(ctx, ":8080")
+func SignalContext(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	return signal.NotifyContext(parent, signals...)
+}
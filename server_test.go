@@ -0,0 +1,629 @@
+package fox
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+)
+
+// freeAddr returns a local address which is free to listen on.
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// waitServer waits until the server on addr accepts connections.
+func waitServer(t *testing.T, addr string) {
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("server %s is not started", addr)
+}
+
+func TestEngineRunContextGracefulShutdown(t *testing.T) {
+	var (
+		router   = New()
+		addr     = freeAddr(t)
+		started  = make(chan struct{})
+		hookRuns = make(chan struct{}, 1)
+	)
+
+	router.GET("/slow", func() string {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		return "done"
+	})
+	router.OnShutdown(func(ctx context.Context) error {
+		hookRuns <- struct{}{}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- router.RunContext(ctx, addr)
+	}()
+	waitServer(t, addr)
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		body <- string(data)
+	}()
+
+	<-started
+	cancel()
+
+	assert.Equal(t, "done", <-body)
+	assert.NoError(t, <-runErr)
+	assert.Len(t, hookRuns, 1)
+
+	_, err := net.Dial("tcp", addr)
+	assert.Error(t, err)
+}
+
+func TestEngineShutdown(t *testing.T) {
+	router := New()
+	addr := freeAddr(t)
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- router.Run(addr)
+	}()
+	waitServer(t, addr)
+
+	assert.NoError(t, router.Shutdown(context.Background()))
+	assert.NoError(t, <-runErr)
+}
+
+func TestEngineShutdownBeforeServe(t *testing.T) {
+	router := New()
+	// Shutdown arrives after the start hooks, before the server is served
+	router.OnStart(func(ctx context.Context) error {
+		return router.Shutdown(context.Background())
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, run := range []func() error{
+		func() error { return router.Run(freeAddr(t)) },
+		func() error { return router.RunListener(listener) },
+		func() error { return router.RunListeners(context.Background(), Endpoint{Addr: freeAddr(t)}) },
+	} {
+		runErr := make(chan error, 1)
+		go func() {
+			runErr <- run()
+		}()
+
+		select {
+		case err := <-runErr:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the engine is still serving after Shutdown")
+		}
+	}
+
+	// the listener is closed
+	_, err = net.Dial("tcp", listener.Addr().String())
+	assert.Error(t, err)
+}
+
+// serveAndShutdown runs router, checks the response of the request by client and runs
+// the checks while serving, then shuts it down.
+func serveAndShutdown(t *testing.T, router *Engine, run func() error, client *http.Client, url string, checks ...func()) {
+	router.GET("/ping", func() string { return "pong" })
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- run()
+	}()
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	for i := 0; i < 100; i++ {
+		if resp, err = client.Get(url); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "pong", string(data))
+	}
+
+	for _, check := range checks {
+		check()
+	}
+
+	assert.NoError(t, router.Shutdown(context.Background()))
+	assert.NoError(t, <-runErr)
+}
+
+func TestEngineRunListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := New()
+	serveAndShutdown(t, router, func() error {
+		return router.RunListener(listener)
+	}, http.DefaultClient, "http://"+listener.Addr().String()+"/ping")
+}
+
+func TestEngineRunFd(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	f, err := listener.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := New()
+	serveAndShutdown(t, router, func() error {
+		return router.RunFd(int(f.Fd()))
+	}, http.DefaultClient, "http://"+listener.Addr().String()+"/ping")
+
+	assert.Error(t, New().RunFd(-1))
+}
+
+func TestListenFds(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "2")
+	assert.Equal(t, []int{3, 4}, ListenFds())
+
+	t.Setenv("LISTEN_PID", "1")
+	assert.Nil(t, ListenFds())
+}
+
+func TestEngineRunUnix(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fox.sock")
+
+	// a stale socket left by a crashed process
+	stale, err := net.Listen("unix", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", file)
+		},
+	}}
+
+	router := New()
+	router.UnixSocketMode = 0660
+	serveAndShutdown(t, router, func() error {
+		return router.RunUnix(file)
+	}, client, "http://unix/ping", func() {
+		info, err := os.Stat(file)
+		if assert.NoError(t, err) {
+			assert.Equal(t, os.FileMode(0660), info.Mode().Perm())
+		}
+		// the socket is in use by router
+		assert.Error(t, New().RunUnix(file))
+	})
+
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	regular := filepath.Join(t.TempDir(), "regular")
+	assert.NoError(t, os.WriteFile(regular, nil, 0600))
+	assert.Error(t, New().RunUnix(regular))
+}
+
+func TestEngineRunTLS(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "cert.pem")
+		keyFile  = filepath.Join(dir, "key.pem")
+		addr     = freeAddr(t)
+	)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool, MaxVersion: tls.VersionTLS12},
+	}}
+
+	router := New()
+	serveAndShutdown(t, router, func() error {
+		return router.RunTLS(addr, certFile, keyFile)
+	}, client, "https://"+addr+"/ping")
+}
+
+func TestEngineServerConfig(t *testing.T) {
+	router := New()
+	assert.Equal(t, defaultReadHeaderTimeout, router.Server.ReadHeaderTimeout)
+	assert.Equal(t, defaultIdleTimeout, router.Server.IdleTimeout)
+	assert.Equal(t, http.DefaultMaxHeaderBytes, router.Server.MaxHeaderBytes)
+
+	var states = make(chan http.ConnState, 10)
+	router.Server.ReadTimeout = time.Second
+	router.Server.WriteTimeout = 2 * time.Second
+	router.Server.ConnState = func(conn net.Conn, state http.ConnState) {
+		states <- state
+	}
+
+	srv := router.newServer(":8080", router)
+	assert.Equal(t, ":8080", srv.Addr)
+	assert.Equal(t, time.Second, srv.ReadTimeout)
+	assert.Equal(t, 2*time.Second, srv.WriteTimeout)
+	assert.Equal(t, defaultReadHeaderTimeout, srv.ReadHeaderTimeout)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	serveAndShutdown(t, router, func() error {
+		return router.RunListener(listener)
+	}, http.DefaultClient, "http://"+listener.Addr().String()+"/ping")
+	assert.Equal(t, http.StateNew, <-states)
+}
+
+func TestEngineRunListeners(t *testing.T) {
+	var (
+		router = New()
+		public = freeAddr(t)
+		admin  = freeAddr(t)
+	)
+
+	router.GET("/users", func() string { return "users" })
+	group := router.Group("/admin")
+	group.GET("/stats", func() string { return "stats" })
+	group.Group("/debug").GET("/vars", func() string { return "vars" })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- router.RunListeners(ctx, Endpoint{Addr: public}, Endpoint{Addr: admin, Group: group})
+	}()
+	waitServer(t, public)
+	waitServer(t, admin)
+
+	get := func(addr, path string) (int, string) {
+		resp, err := http.Get("http://" + addr + path)
+		if !assert.NoError(t, err) {
+			return 0, ""
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	code, body := get(public, "/users")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "users", body)
+
+	code, _ = get(public, "/admin/stats")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = get(admin, "/users")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, body = get(admin, "/admin/stats")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "stats", body)
+
+	code, body = get(admin, "/admin/debug/vars")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "vars", body)
+
+	req, _ := http.NewRequest(http.MethodPost, "http://"+public+"/admin/stats", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+
+	cancel()
+	assert.NoError(t, <-runErr)
+
+	_, err = net.Dial("tcp", admin)
+	assert.Error(t, err)
+
+	// the group is unbound once RunListeners returns
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/stats", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "stats", w.Body.String())
+}
+
+func TestEngineBoundGroupsRedirect(t *testing.T) {
+	router := New()
+	router.GET("/users", func() string { return "users" })
+	group := router.Group("/admin")
+	group.GET("/stats", func() string { return "stats" })
+	unbind := router.bindGroups(nil, group)
+
+	serve := func(scope *RouterGroup, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.serveHTTP(w, httptest.NewRequest(http.MethodGet, path, nil), scope)
+		return w
+	}
+
+	// the redirects of every endpoint are kept, but only to its own routes
+	w := serve(nil, "/users/")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/users", w.Header().Get("Location"))
+	assert.Equal(t, http.StatusMovedPermanently, serve(nil, "/USERS").Code)
+	assert.Equal(t, http.StatusNotFound, serve(nil, "/admin/stats/").Code)
+	assert.Equal(t, http.StatusNotFound, serve(nil, "/ADMIN/stats").Code)
+
+	w = serve(group, "/admin/stats/")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/admin/stats", w.Header().Get("Location"))
+	assert.Equal(t, http.StatusMovedPermanently, serve(group, "/ADMIN/stats").Code)
+	assert.Equal(t, http.StatusNotFound, serve(group, "/users/").Code)
+	assert.Equal(t, http.StatusNotFound, serve(group, "/USERS").Code)
+
+	// the bindings are counted, the group is unbound when the last one is removed
+	unbindAgain := router.bindGroups(group)
+	unbind()
+	assert.Equal(t, http.StatusNotFound, serve(nil, "/admin/stats/").Code)
+	unbindAgain()
+	assert.Equal(t, http.StatusMovedPermanently, serve(nil, "/admin/stats/").Code)
+	assert.Equal(t, http.StatusOK, serve(nil, "/admin/stats").Code)
+}
+
+func TestEngineRunListenersError(t *testing.T) {
+	router := New()
+	assert.Error(t, router.RunListeners(context.Background()))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	// the address is in use, the listeners opened before are closed
+	other := freeAddr(t)
+	assert.Error(t, router.RunListeners(context.Background(), Endpoint{Addr: other}, Endpoint{Addr: listener.Addr().String()}))
+	ln, err := net.Listen("tcp", other)
+	if assert.NoError(t, err) {
+		ln.Close()
+	}
+}
+
+// h2cClient returns a client speaking HTTP/2 without TLS with prior knowledge.
+func h2cClient() *http.Client {
+	return &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+func TestEngineH2C(t *testing.T) {
+	router := New()
+	router.H2C = true
+	router.GET("/stream", func(c *Context) {
+		c.Writer.Header().Set("Content-Type", "text/plain")
+		i := 0
+		c.Stream(func(w io.Writer) bool {
+			i++
+			io.WriteString(w, strconv.Itoa(i))
+			return i < 3
+		})
+		c.SetTrailer("X-Checksum", "123")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+
+	serveAndShutdown(t, router, func() error {
+		return router.RunListener(listener)
+	}, h2cClient(), "http://"+addr+"/ping", func() {
+		resp, err := h2cClient().Get("http://" + addr + "/stream")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+		assert.Equal(t, 2, resp.ProtoMajor)
+
+		data, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "123", string(data))
+		assert.Equal(t, "123", resp.Trailer.Get("X-Checksum"))
+
+		// upgrade from HTTP/1.1
+		conn, err := net.Dial("tcp", addr)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "GET /ping HTTP/1.1\r\nHost: "+addr+"\r\n"+
+			"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQAoAAAAAIAAAAA\r\n\r\n")
+		reader := bufio.NewReader(conn)
+		line, _ := reader.ReadString('\n')
+		assert.Equal(t, "HTTP/1.1 101 Switching Protocols\r\n", line)
+		for line != "\r\n" && line != "" {
+			line, _ = reader.ReadString('\n')
+		}
+
+		// send the client preface and read the response of the upgraded request
+		framer := http2.NewFramer(conn, reader)
+		io.WriteString(conn, http2.ClientPreface)
+		assert.NoError(t, framer.WriteSettings())
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		for {
+			frame, err := framer.ReadFrame()
+			if !assert.NoError(t, err) {
+				return
+			}
+			if frame.Header().StreamID == 1 && frame.Header().Flags.Has(http2.FlagDataEndStream) {
+				break
+			}
+		}
+	})
+}
+
+func TestEngineH2CDisabled(t *testing.T) {
+	router := New()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+
+	serveAndShutdown(t, router, func() error {
+		return router.RunListener(listener)
+	}, http.DefaultClient, "http://"+addr+"/ping", func() {
+		_, err := h2cClient().Get("http://" + addr + "/ping")
+		assert.Error(t, err)
+	})
+}
+
+func TestEngineOnStart(t *testing.T) {
+	var (
+		router = New()
+		addr   = freeAddr(t)
+		calls  []string
+	)
+
+	router.OnStart(func(ctx context.Context) error {
+		calls = append(calls, "workers")
+		return nil
+	}, func(ctx context.Context) error {
+		calls = append(calls, "cache")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- router.RunContext(ctx, addr)
+	}()
+	waitServer(t, addr)
+	cancel()
+	assert.NoError(t, <-runErr)
+	assert.Equal(t, []string{"workers", "cache"}, calls)
+
+	// a failed hook aborts the startup
+	errHook := errors.New("cache is unavailable")
+	router.OnStart(func(ctx context.Context) error {
+		return errHook
+	}, func(ctx context.Context) error {
+		calls = append(calls, "never")
+		return nil
+	})
+	assert.Equal(t, errHook, router.RunContext(context.Background(), addr))
+	assert.Equal(t, errHook, router.RunListeners(context.Background(), Endpoint{Addr: addr}))
+	assert.Equal(t, errHook, router.RunUnix(filepath.Join(t.TempDir(), "fox.sock")))
+	assert.NotContains(t, calls, "never")
+
+	_, err := net.Dial("tcp", addr)
+	assert.Error(t, err)
+}
+
+func TestEngineStartFailure(t *testing.T) {
+	var (
+		router = New()
+		calls  []string
+	)
+
+	router.OnStart(func(ctx context.Context) error {
+		calls = append(calls, "start")
+		return nil
+	})
+	router.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, "shutdown")
+		return nil
+	})
+
+	// the listener fails after the start hooks ran
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	assert.Error(t, router.RunContext(context.Background(), listener.Addr().String()))
+	assert.Equal(t, []string{"start", "shutdown"}, calls)
+
+	calls = nil
+	assert.Error(t, router.RunListeners(context.Background(), Endpoint{Addr: listener.Addr().String()}))
+	assert.Equal(t, []string{"start", "shutdown"}, calls)
+
+	calls = nil
+	assert.Error(t, router.RunUnix("/nonexistent/dir/fox.sock"))
+	assert.Equal(t, []string{"start", "shutdown"}, calls)
+
+	// a failed start hook releases what the hooks before acquired
+	calls = nil
+	errHook := errors.New("cache is unavailable")
+	router.OnStart(func(ctx context.Context) error {
+		return errHook
+	})
+	assert.Equal(t, errHook, router.RunContext(context.Background(), listener.Addr().String()))
+	assert.Equal(t, []string{"start", "shutdown"}, calls)
+}
@@ -0,0 +1,54 @@
+package fox
+
+import (
+	"github.com/miclle/fox/sessions"
+)
+
+// Sessions returns a middleware which provides the session of name kept by store to
+// Context.Session. The modified session is saved before the response header is written.
+//
+//	store, err := sessions.NewCookieStore(hashKey, blockKey)
+//	...
+//	router.Use(fox.Sessions("session", store))
+//	router.POST("/login", func(c *fox.Context, args *LoginArgs) (any, error) {
+//		...
+//		session := c.Session()
+//		session.Regenerate()
+//		session.Set("user", user.ID)
+//		session.AddFlash("Welcome back!")
+//		return render.Redirect{Code: http.StatusSeeOther, Location: "/"}, nil
+//	})
+func Sessions(name string, store sessions.Store) HandlerFunc {
+	return func(c *Context) {
+		c.sessionName = name
+		c.sessionStore = store
+		c.session = nil
+		c.Writer.beforeWrite = append(c.Writer.beforeWrite, c.saveSession)
+	}
+}
+
+// Session returns the session of the request, it's loaded on the first call.
+// It panics if the Sessions middleware isn't used.
+func (c *Context) Session() *sessions.Session {
+	if c.session == nil {
+		if c.sessionStore == nil {
+			panic("fox: Session requires the Sessions middleware")
+		}
+
+		session, err := c.sessionStore.Load(c.Request, c.sessionName)
+		if err != nil {
+			c.Logger().Warn("invalid session", "error", err)
+		}
+		c.session = session
+	}
+	return c.session
+}
+
+// saveSession saves the session if it's modified.
+func (c *Context) saveSession() {
+	if c.session != nil && c.session.Modified() {
+		if err := c.session.Save(c.Writer, c.Request); err != nil {
+			c.Logger().Error("save session failed", "error", err)
+		}
+	}
+}
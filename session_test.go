@@ -0,0 +1,92 @@
+package fox
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/miclle/fox/render"
+	"github.com/miclle/fox/sessions"
+)
+
+func TestSessions(t *testing.T) {
+	store := sessions.NewMemoryStore(time.Hour)
+
+	router := New()
+	router.Use(Sessions("session", store))
+	router.POST("/login", func(c *Context) render.Redirect {
+		session := c.Session()
+		session.Regenerate()
+		session.Set("user", c.Request.Header.Get("X-User"))
+		session.AddFlash("welcome")
+		return render.Redirect{Code: http.StatusSeeOther, Request: c.Request, Location: "/"}
+	})
+	router.GET("/", func(c *Context) string {
+		session := c.Session()
+		user, _ := session.Get("user").(string)
+		for _, flash := range session.Flashes() {
+			user += " " + flash.(string)
+		}
+		return user
+	})
+	router.GET("/logout", func(c *Context) {
+		c.Session().Destroy()
+		c.Writer.WriteHeader(http.StatusNoContent)
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/", http.Header{})
+	assert.Equal(t, "", w.Body.String())
+	assert.Empty(t, w.Header().Get("Set-Cookie"), "unmodified sessions aren't saved")
+
+	w = PerformRequest(router, http.MethodPost, "/login", http.Header{"X-User": []string{"alice"}})
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	header := http.Header{"Cookie": []string{cookies[0].String()}}
+
+	w = PerformRequest(router, http.MethodGet, "/", header)
+	assert.Equal(t, "alice welcome", w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Set-Cookie"), "the read flashes are deleted")
+
+	w = PerformRequest(router, http.MethodGet, "/", header)
+	assert.Equal(t, "alice", w.Body.String())
+
+	// the session ID changes on login
+	w = PerformRequest(router, http.MethodPost, "/login", http.Header{"X-User": []string{"bob"}, "Cookie": header["Cookie"]})
+	newCookies := w.Result().Cookies()
+	assert.NotEqual(t, cookies[0].Value, newCookies[0].Value)
+	w = PerformRequest(router, http.MethodGet, "/", header)
+	assert.Equal(t, "", w.Body.String())
+	header = http.Header{"Cookie": []string{newCookies[0].String()}}
+
+	w = PerformRequest(router, http.MethodGet, "/logout", header)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+	w = PerformRequest(router, http.MethodGet, "/", header)
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestSessionsInvalidCookie(t *testing.T) {
+	store, err := sessions.NewCookieStore(bytes.Repeat([]byte("k"), 32), bytes.Repeat([]byte("k"), 32))
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	router := New()
+	router.Logger = NewLogger(&out, LevelWarn)
+	router.Use(Sessions("session", store))
+	router.GET("/", func(c *Context) bool {
+		return c.Session().IsNew
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/", http.Header{"Cookie": []string{"session=tampered"}})
+	assert.Equal(t, "true", w.Body.String())
+	assert.Contains(t, out.String(), `level=WARN msg="invalid session"`)
+	assert.Contains(t, out.String(), `error="sessions: the cookie is invalid"`)
+
+	router = New()
+	router.GET("/", func(c *Context) { c.Session() })
+	assert.Panics(t, func() { PerformRequest(router, http.MethodGet, "/", nil) })
+}
@@ -0,0 +1,216 @@
+package sessions
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The errors of an invalid session cookie.
+var (
+	ErrInvalidCookie = errors.New("sessions: the cookie is invalid")
+	ErrExpiredCookie = errors.New("sessions: the cookie is expired")
+	ErrCookieTooLong = errors.New("sessions: the cookie is too long")
+)
+
+// maxCookieLength is the max length of a cookie value supported by the browsers.
+const maxCookieLength = 4096
+
+// CookieStore keeps the values of the sessions in the cookies, signed by HMAC-SHA256 and
+// encrypted by AES-GCM.
+type CookieStore struct {
+	// Options are the attributes of the cookies, see DefaultOptions.
+	Options Options
+
+	codecs []*codec
+}
+
+// NewCookieStore returns a CookieStore of the key pairs, a hash key of 32 or 64 bytes to
+// sign the cookies and a block key of 16, 24 or 32 bytes to encrypt them. The block key
+// may be nil to only sign the cookies. The first pair encodes the cookies, all the pairs
+// decode them, prepend a new pair to rotate the keys:
+//
+//	store, err := sessions.NewCookieStore(newHashKey, newBlockKey, oldHashKey, oldBlockKey)
+func NewCookieStore(keyPairs ...[]byte) (*CookieStore, error) {
+	if len(keyPairs) == 0 {
+		return nil, errors.New("sessions: a hash key is required")
+	}
+
+	store := &CookieStore{Options: DefaultOptions()}
+	for i := 0; i < len(keyPairs); i += 2 {
+		var blockKey []byte
+		if i+1 < len(keyPairs) {
+			blockKey = keyPairs[i+1]
+		}
+		c, err := newCodec(keyPairs[i], blockKey)
+		if err != nil {
+			return nil, err
+		}
+		store.codecs = append(store.codecs, c)
+	}
+	return store, nil
+}
+
+// Load implements the Store interface.
+func (store *CookieStore) Load(r *http.Request, name string) (*Session, error) {
+	session := NewSession(store, name, store.Options)
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	var data cookieData
+	if err = store.decode(name, cookie.Value, &data); err != nil {
+		return session, err
+	}
+	session.ID = data.ID
+	session.SetValues(data.Values)
+	session.IsNew = false
+	return session, nil
+}
+
+// Save implements the Store interface.
+func (store *CookieStore) Save(w http.ResponseWriter, r *http.Request, session *Session) error {
+	if session.Options.MaxAge < 0 {
+		http.SetCookie(w, session.Options.cookie(session.Name, ""))
+		return nil
+	}
+
+	data := cookieData{ID: session.ID, Values: session.Values()}
+	value, err := store.codecs[0].encode(session.Name, data, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, session.Options.cookie(session.Name, value))
+	return nil
+}
+
+// cookieData is the content of the session cookie.
+type cookieData struct {
+	ID     string
+	Values map[string]any
+}
+
+// decode decodes the value by the codecs in turn, and checks its age.
+func (store *CookieStore) decode(name, value string, data *cookieData) (err error) {
+	for _, c := range store.codecs {
+		var timestamp int64
+		if timestamp, err = c.decode(name, value, data); err != nil {
+			continue
+		}
+		if maxAge := store.Options.MaxAge; maxAge > 0 && time.Now().Unix()-timestamp > int64(maxAge) {
+			return ErrExpiredCookie
+		}
+		return nil
+	}
+	return err
+}
+
+// codec signs and encrypts the cookie values.
+type codec struct {
+	hashKey []byte
+	aead    cipher.AEAD
+}
+
+func newCodec(hashKey, blockKey []byte) (*codec, error) {
+	if len(hashKey) < 32 {
+		return nil, errors.New("sessions: the hash key must be at least 32 bytes")
+	}
+
+	c := &codec{hashKey: hashKey}
+	if blockKey != nil {
+		block, err := aes.NewCipher(blockKey)
+		if err != nil {
+			return nil, fmt.Errorf("sessions: invalid block key: %w", err)
+		}
+		if c.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// encode returns base64(timestamp|payload|mac), the payload is the encrypted gob of v,
+// the mac signs the cookie name, the timestamp and the payload.
+func (c *codec) encode(name string, v any, timestamp int64) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return "", fmt.Errorf("sessions: %w", err)
+	}
+	payload := buf.Bytes()
+
+	if c.aead != nil {
+		nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(payload)+c.aead.Overhead())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		payload = c.aead.Seal(nonce, nonce, payload, []byte(name))
+	}
+
+	data := strconv.FormatInt(timestamp, 10) + "|" + base64.RawURLEncoding.EncodeToString(payload)
+	value := base64.RawURLEncoding.EncodeToString([]byte(data + "|" + string(c.mac(name, data))))
+	if len(value) > maxCookieLength {
+		return "", ErrCookieTooLong
+	}
+	return value, nil
+}
+
+// decode verifies and decodes the value into v, it returns the timestamp of the value.
+func (c *codec) decode(name, value string, v any) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, ErrInvalidCookie
+	}
+
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 {
+		return 0, ErrInvalidCookie
+	}
+	data := parts[0] + "|" + parts[1]
+	if !hmac.Equal([]byte(parts[2]), c.mac(name, data)) {
+		return 0, ErrInvalidCookie
+	}
+
+	timestamp, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCookie
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, ErrInvalidCookie
+	}
+
+	if c.aead != nil {
+		size := c.aead.NonceSize()
+		if len(payload) < size {
+			return 0, ErrInvalidCookie
+		}
+		if payload, err = c.aead.Open(nil, payload[:size], payload[size:], []byte(name)); err != nil {
+			return 0, ErrInvalidCookie
+		}
+	}
+
+	if err = gob.NewDecoder(bytes.NewReader(payload)).Decode(v); err != nil {
+		return 0, ErrInvalidCookie
+	}
+	return timestamp, nil
+}
+
+// mac returns the HMAC-SHA256 of the cookie name and data.
+func (c *codec) mac(name, data string) []byte {
+	h := hmac.New(sha256.New, c.hashKey)
+	h.Write([]byte(name + "|" + data))
+	return h.Sum(nil)
+}
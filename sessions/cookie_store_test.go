@@ -0,0 +1,139 @@
+package sessions
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	hashKey  = bytes.Repeat([]byte("h"), 32)
+	blockKey = bytes.Repeat([]byte("b"), 32)
+)
+
+// roundTrip saves the session and returns a request with the cookie of the response.
+func roundTrip(t *testing.T, session *Session) (*http.Request, *http.Cookie) {
+	w := httptest.NewRecorder()
+	assert.NoError(t, session.Save(w, httptest.NewRequest(http.MethodGet, "/", nil)))
+
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	return req, cookies[0]
+}
+
+func TestCookieStore(t *testing.T) {
+	store, err := NewCookieStore(hashKey, blockKey)
+	assert.NoError(t, err)
+
+	session, err := store.Load(httptest.NewRequest(http.MethodGet, "/", nil), "session")
+	assert.NoError(t, err)
+	assert.True(t, session.IsNew)
+
+	session.Set("user", "alice")
+	session.AddFlash("welcome")
+	req, cookie := roundTrip(t, session)
+	assert.False(t, session.Modified())
+	assert.NotContains(t, cookie.Value, "alice")
+	assert.True(t, cookie.HttpOnly)
+
+	loaded, err := store.Load(req, "session")
+	assert.NoError(t, err)
+	assert.False(t, loaded.IsNew)
+	assert.Equal(t, session.ID, loaded.ID)
+	assert.Equal(t, "alice", loaded.Get("user"))
+	assert.Equal(t, []any{"welcome"}, loaded.Flashes())
+
+	// the cookie is bound to its name
+	req.Header.Set("Cookie", "other="+cookie.Value)
+	_, err = store.Load(req, "other")
+	assert.ErrorIs(t, err, ErrInvalidCookie)
+
+	// tampered
+	req.Header.Set("Cookie", "session="+strings.ToUpper(cookie.Value[:10])+cookie.Value[10:])
+	session, err = store.Load(req, "session")
+	assert.ErrorIs(t, err, ErrInvalidCookie)
+	assert.True(t, session.IsNew)
+	assert.Nil(t, session.Get("user"))
+
+	// destroyed
+	loaded.Destroy()
+	_, cookie = roundTrip(t, loaded)
+	assert.Empty(t, cookie.Value)
+	assert.Equal(t, -1, cookie.MaxAge)
+}
+
+func TestCookieStoreKeyRotation(t *testing.T) {
+	oldStore, err := NewCookieStore(hashKey, blockKey)
+	assert.NoError(t, err)
+
+	session := NewSession(oldStore, "session", oldStore.Options)
+	session.Set("user", "alice")
+	req, _ := roundTrip(t, session)
+
+	newHashKey := bytes.Repeat([]byte("H"), 64)
+	newBlockKey := bytes.Repeat([]byte("B"), 16)
+	store, err := NewCookieStore(newHashKey, newBlockKey, hashKey, blockKey)
+	assert.NoError(t, err)
+
+	loaded, err := store.Load(req, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", loaded.Get("user"))
+
+	// re-encoded with the new keys
+	loaded.MarkModified()
+	req, _ = roundTrip(t, loaded)
+	_, err = oldStore.Load(req, "session")
+	assert.ErrorIs(t, err, ErrInvalidCookie)
+
+	newStore, err := NewCookieStore(newHashKey, newBlockKey)
+	assert.NoError(t, err)
+	loaded, err = newStore.Load(req, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", loaded.Get("user"))
+}
+
+func TestCookieStoreSignedOnly(t *testing.T) {
+	store, err := NewCookieStore(hashKey)
+	assert.NoError(t, err)
+
+	session := NewSession(store, "session", store.Options)
+	session.Set("user", "alice")
+	req, _ := roundTrip(t, session)
+
+	loaded, err := store.Load(req, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", loaded.Get("user"))
+}
+
+func TestCookieStoreErrors(t *testing.T) {
+	_, err := NewCookieStore()
+	assert.Error(t, err)
+	_, err = NewCookieStore([]byte("short"))
+	assert.Error(t, err)
+	_, err = NewCookieStore(hashKey, []byte("bad block key"))
+	assert.Error(t, err)
+
+	store, err := NewCookieStore(hashKey, blockKey)
+	assert.NoError(t, err)
+	session := NewSession(store, "session", store.Options)
+	session.Set("data", strings.Repeat("x", maxCookieLength))
+	assert.ErrorIs(t, session.Save(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)), ErrCookieTooLong)
+	assert.True(t, session.Modified())
+
+	// expired
+	value, err := store.codecs[0].encode("session", cookieData{ID: "id"}, time.Now().Unix()-3600)
+	assert.NoError(t, err)
+	var data cookieData
+	store.Options.MaxAge = 60
+	assert.ErrorIs(t, store.decode("session", value, &data), ErrExpiredCookie)
+	store.Options.MaxAge = 0
+	assert.NoError(t, store.decode("session", value, &data))
+	assert.Equal(t, "id", data.ID)
+}
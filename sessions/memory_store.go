@@ -0,0 +1,110 @@
+package sessions
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the values of the sessions in memory and the session ID in the cookie.
+// The sessions expire after TTL without requests. The sessions are lost on restart and
+// aren't shared between the instances, implement the Store interface on a database to share them.
+type MemoryStore struct {
+	// Options are the attributes of the cookies, see DefaultOptions.
+	Options Options
+
+	ttl       time.Duration
+	mu        sync.Mutex
+	sessions  map[string]*memorySession
+	nextSweep time.Time
+}
+
+type memorySession struct {
+	values  map[string]any
+	expires time.Time
+}
+
+// NewMemoryStore returns a MemoryStore, the sessions expire after ttl without requests.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	options := DefaultOptions()
+	options.MaxAge = int(ttl / time.Second)
+	return &MemoryStore{Options: options, ttl: ttl, sessions: make(map[string]*memorySession)}
+}
+
+// Load implements the Store interface, the expiry of the session is extended.
+func (store *MemoryStore) Load(r *http.Request, name string) (*Session, error) {
+	session := NewSession(store, name, store.Options)
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	now := time.Now()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	s := store.sessions[cookie.Value]
+	if s == nil || now.After(s.expires) {
+		return session, nil
+	}
+	s.expires = now.Add(store.ttl)
+
+	session.ID = cookie.Value
+	session.SetValues(copyValues(s.values))
+	session.IsNew = false
+	return session, nil
+}
+
+// Save implements the Store interface, the previous session of a regenerated session is deleted.
+func (store *MemoryStore) Save(w http.ResponseWriter, r *http.Request, session *Session) error {
+	now := time.Now()
+
+	store.mu.Lock()
+	if now.After(store.nextSweep) {
+		store.evictExpired(now)
+		store.nextSweep = now.Add(store.ttl)
+	}
+	if id := session.PreviousID(); id != "" {
+		delete(store.sessions, id)
+	}
+	if session.Options.MaxAge < 0 {
+		delete(store.sessions, session.ID)
+	} else {
+		store.sessions[session.ID] = &memorySession{values: copyValues(session.Values()), expires: now.Add(store.ttl)}
+	}
+	store.mu.Unlock()
+
+	value := session.ID
+	if session.Options.MaxAge < 0 {
+		value = ""
+	}
+	http.SetCookie(w, session.Options.cookie(session.Name, value))
+	return nil
+}
+
+// Len returns the number of the sessions, including the expired ones not evicted yet.
+func (store *MemoryStore) Len() int {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return len(store.sessions)
+}
+
+// evictExpired deletes the expired sessions.
+func (store *MemoryStore) evictExpired(now time.Time) {
+	for id, s := range store.sessions {
+		if now.After(s.expires) {
+			delete(store.sessions, id)
+		}
+	}
+}
+
+// copyValues returns a shallow copy of the values, so the requests don't share the map.
+func copyValues(values map[string]any) map[string]any {
+	c := make(map[string]any, len(values))
+	for k, v := range values {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,71 @@
+package sessions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	assert.Equal(t, 3600, store.Options.MaxAge)
+
+	session, err := store.Load(httptest.NewRequest(http.MethodGet, "/", nil), "session")
+	assert.NoError(t, err)
+	assert.True(t, session.IsNew)
+
+	session.Set("user", "alice")
+	req, cookie := roundTrip(t, session)
+	assert.Equal(t, session.ID, cookie.Value)
+	assert.Equal(t, 1, store.Len())
+
+	loaded, err := store.Load(req, "session")
+	assert.NoError(t, err)
+	assert.False(t, loaded.IsNew)
+	assert.Equal(t, "alice", loaded.Get("user"))
+
+	// the requests don't share the values
+	loaded.Set("user", "bob")
+	loaded, err = store.Load(req, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", loaded.Get("user"))
+
+	// the previous session is deleted
+	loaded.Regenerate()
+	newReq, cookie := roundTrip(t, loaded)
+	assert.NotEqual(t, req.Header.Get("Cookie"), newReq.Header.Get("Cookie"))
+	assert.Equal(t, 1, store.Len())
+	session, err = store.Load(req, "session")
+	assert.NoError(t, err)
+	assert.True(t, session.IsNew)
+	session, err = store.Load(newReq, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", session.Get("user"))
+
+	session.Destroy()
+	_, cookie = roundTrip(t, session)
+	assert.Empty(t, cookie.Value)
+	assert.Zero(t, store.Len())
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+
+	session := NewSession(store, "session", store.Options)
+	session.Set("user", "alice")
+	req, _ := roundTrip(t, session)
+
+	store.sessions[session.ID].expires = time.Now().Add(-time.Second)
+	loaded, err := store.Load(req, "session")
+	assert.NoError(t, err)
+	assert.True(t, loaded.IsNew)
+
+	// the expired sessions are evicted
+	store.nextSweep = time.Time{}
+	loaded.Set("user", "bob")
+	roundTrip(t, loaded)
+	assert.Equal(t, 1, store.Len())
+}
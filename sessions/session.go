@@ -0,0 +1,219 @@
+// Package sessions provides the sessions of fox: a map-like Session, kept by a Store
+// between the requests. NewCookieStore keeps the values in a signed and encrypted cookie,
+// NewMemoryStore keeps them in memory and only the session ID in the cookie.
+//
+// The values are encoded with encoding/gob, register the custom types with gob.Register.
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"net/http"
+	"sort"
+)
+
+// flashesKey is the default key of the flash messages.
+const flashesKey = "_flash"
+
+func init() {
+	// the type of the flash messages
+	gob.Register([]any{})
+}
+
+// Options are the attributes of the session cookie.
+type Options struct {
+	Path   string
+	Domain string
+	// MaxAge is the lifetime of the session in seconds, the cookie is deleted if it's < 0.
+	MaxAge   int
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// DefaultOptions returns the default options of the stores: the path "/", 30 days,
+// HttpOnly and SameSite=Lax.
+func DefaultOptions() Options {
+	return Options{Path: "/", MaxAge: 86400 * 30, HttpOnly: true, SameSite: http.SameSiteLaxMode}
+}
+
+// cookie returns the session cookie of name with value.
+func (options Options) cookie(name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     options.Path,
+		Domain:   options.Domain,
+		MaxAge:   options.MaxAge,
+		Secure:   options.Secure,
+		HttpOnly: options.HttpOnly,
+		SameSite: options.SameSite,
+	}
+}
+
+// Store loads and saves the sessions.
+type Store interface {
+	// Load returns the session of name of the request, a new session if it's missing.
+	// If the session is invalid, e.g. tampered or expired, a new session is returned with the error.
+	Load(r *http.Request, name string) (*Session, error)
+
+	// Save writes the session to the response, the session is deleted if Options.MaxAge < 0.
+	Save(w http.ResponseWriter, r *http.Request, session *Session) error
+}
+
+// Session is the map-like session of a request.
+type Session struct {
+	// ID is the identifier of the session, it's kept secret in the stores.
+	ID string
+
+	// Name is the name of the session cookie.
+	Name string
+
+	// Options are the attributes of the session cookie.
+	Options Options
+
+	// IsNew is true if the session was created by the request.
+	IsNew bool
+
+	store    Store
+	values   map[string]any
+	modified bool
+
+	// previousID is the ID before Regenerate, deleted from the store when it's saved.
+	previousID string
+}
+
+// NewSession returns a new session of name with a random ID, for the Store implementations.
+func NewSession(store Store, name string, options Options) *Session {
+	return &Session{ID: NewID(), Name: name, Options: options, IsNew: true, store: store, values: make(map[string]any)}
+}
+
+// Save saves the session by its store.
+func (s *Session) Save(w http.ResponseWriter, r *http.Request) error {
+	if err := s.store.Save(w, r, s); err != nil {
+		return err
+	}
+	s.modified = false
+	s.previousID = ""
+	s.IsNew = false
+	return nil
+}
+
+// Get returns the value of key, nil if it's missing.
+func (s *Session) Get(key string) any {
+	return s.values[key]
+}
+
+// Set sets the value of key.
+func (s *Session) Set(key string, value any) {
+	s.values[key] = value
+	s.modified = true
+}
+
+// Delete deletes the value of key.
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.modified = true
+	}
+}
+
+// Clear deletes all the values.
+func (s *Session) Clear() {
+	if len(s.values) > 0 {
+		s.values = make(map[string]any)
+		s.modified = true
+	}
+}
+
+// Keys returns the sorted keys of the values.
+func (s *Session) Keys() []string {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Len returns the number of the values.
+func (s *Session) Len() int {
+	return len(s.values)
+}
+
+// Values returns the values, the changes aren't tracked, call Set or MarkModified.
+func (s *Session) Values() map[string]any {
+	return s.values
+}
+
+// AddFlash adds a flash message, which is deleted once it's read by Flashes.
+// The messages are added under "_flash", or the key if it's given.
+func (s *Session) AddFlash(value any, key ...string) {
+	k := flashesKey
+	if len(key) > 0 {
+		k = key[0]
+	}
+	flashes, _ := s.values[k].([]any)
+	s.Set(k, append(flashes[:len(flashes):len(flashes)], value))
+}
+
+// Flashes returns and deletes the flash messages of "_flash", or the key if it's given.
+func (s *Session) Flashes(key ...string) []any {
+	k := flashesKey
+	if len(key) > 0 {
+		k = key[0]
+	}
+	flashes, _ := s.values[k].([]any)
+	s.Delete(k)
+	return flashes
+}
+
+// Regenerate changes the ID of the session and keeps the values, call it on login and
+// privilege changes to prevent the session fixation.
+func (s *Session) Regenerate() {
+	if s.previousID == "" && !s.IsNew {
+		s.previousID = s.ID
+	}
+	s.ID = NewID()
+	s.modified = true
+}
+
+// Destroy deletes the values and the session cookie.
+func (s *Session) Destroy() {
+	s.values = make(map[string]any)
+	s.Options.MaxAge = -1
+	s.modified = true
+}
+
+// MarkModified marks the session to be saved, e.g. after a value is changed in place.
+func (s *Session) MarkModified() {
+	s.modified = true
+}
+
+// Modified reports whether the session is changed and needs to be saved.
+func (s *Session) Modified() bool {
+	return s.modified
+}
+
+// PreviousID returns the ID of the session before Regenerate, "" if it isn't regenerated.
+func (s *Session) PreviousID() string {
+	return s.previousID
+}
+
+// SetValues replaces the values of the loaded session, for the Store implementations.
+func (s *Session) SetValues(values map[string]any) {
+	if values == nil {
+		values = make(map[string]any)
+	}
+	s.values = values
+}
+
+// NewID returns a random session ID of 32 bytes.
+func NewID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
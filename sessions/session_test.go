@@ -0,0 +1,65 @@
+package sessions
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	s := NewSession(nil, "session", DefaultOptions())
+	assert.True(t, s.IsNew)
+	assert.Len(t, s.ID, 43)
+	assert.False(t, s.Modified())
+
+	s.Set("user", "alice")
+	s.Set("age", 18)
+	assert.True(t, s.Modified())
+	assert.Equal(t, "alice", s.Get("user"))
+	assert.Nil(t, s.Get("missing"))
+	assert.Equal(t, []string{"age", "user"}, s.Keys())
+	assert.Equal(t, 2, s.Len())
+
+	s.Delete("age")
+	assert.Equal(t, []string{"user"}, s.Keys())
+
+	s.AddFlash("saved")
+	s.AddFlash("error", "errors")
+	assert.Equal(t, []any{"saved"}, s.Flashes())
+	assert.Nil(t, s.Flashes())
+	assert.Equal(t, []any{"error"}, s.Flashes("errors"))
+
+	s.Clear()
+	assert.Zero(t, s.Len())
+
+	s.Destroy()
+	assert.Equal(t, -1, s.Options.MaxAge)
+}
+
+func TestSessionRegenerate(t *testing.T) {
+	s := NewSession(nil, "session", DefaultOptions())
+	id := s.ID
+
+	// a new session has nothing to delete
+	s.Regenerate()
+	assert.NotEqual(t, id, s.ID)
+	assert.Empty(t, s.PreviousID())
+
+	s.IsNew = false
+	id = s.ID
+	s.Set("user", "alice")
+	s.Regenerate()
+	s.Regenerate()
+	assert.NotEqual(t, id, s.ID)
+	assert.Equal(t, id, s.PreviousID())
+	assert.Equal(t, "alice", s.Get("user"))
+}
+
+func TestOptionsCookie(t *testing.T) {
+	cookie := DefaultOptions().cookie("session", "value")
+	assert.Equal(t, "/", cookie.Path)
+	assert.Equal(t, 86400*30, cookie.MaxAge)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+}
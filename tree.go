@@ -114,6 +114,7 @@ type node struct {
 	priority  uint32
 	children  []*node
 	handlers  HandlersChain
+	fullPath  string
 }
 
 // Increments priority of the given child and reorders if necessary
@@ -168,6 +169,7 @@ walk:
 				indices:   n.indices,
 				children:  n.children,
 				handlers:  n.handlers,
+				fullPath:  n.fullPath,
 				priority:  n.priority - 1,
 			}
 
@@ -176,6 +178,7 @@ walk:
 			n.indices = string([]byte{n.path[i]})
 			n.path = path[:i]
 			n.handlers = nil
+			n.fullPath = ""
 			n.wildChild = false
 		}
 
@@ -245,6 +248,7 @@ walk:
 			panic("a handle is already registered for path '" + fullPath + "'")
 		}
 		n.handlers = handlers
+		n.fullPath = fullPath
 		return
 	}
 }
@@ -306,6 +310,7 @@ func (n *node) insertChild(path, fullPath string, handlers HandlersChain) {
 
 			// Otherwise we're done. Insert the handle in the new leaf
 			n.handlers = handlers
+			n.fullPath = fullPath
 			return
 		}
 
@@ -341,6 +346,7 @@ func (n *node) insertChild(path, fullPath string, handlers HandlersChain) {
 			path:     path[i:],
 			nType:    catchAll,
 			handlers: handlers,
+			fullPath: fullPath,
 			priority: 1,
 		}
 		n.children = []*node{child}
@@ -351,14 +357,15 @@ func (n *node) insertChild(path, fullPath string, handlers HandlersChain) {
 	// If no wildcard was found, simply insert the path and handle
 	n.path = path
 	n.handlers = handlers
+	n.fullPath = fullPath
 }
 
-// Returns the handle registered with the given path (key). The values of
-// wildcards are saved to a map.
+// Returns the handle registered with the given path (key) and the full path
+// of the route. The values of wildcards are saved to a map.
 // If no handle can be found, a TSR (trailing slash redirect) recommendation is
 // made if a handle exists with an extra (without the) trailing slash for the
 // given path.
-func (n *node) getValue(path string, params *Params) (handle HandlersChain, ps *Params, tsr bool) {
+func (n *node) getValue(path string, params *Params) (handle HandlersChain, ps *Params, tsr bool, fullPath string) {
 walk: // Outer loop for walking the tree
 	for {
 		prefix := n.path
@@ -423,6 +430,7 @@ walk: // Outer loop for walking the tree
 					}
 
 					if handle = n.handlers; handle != nil {
+						fullPath = n.fullPath
 						return
 					} else if len(n.children) == 1 {
 						// No handle found. Check if a handle for this path + a
@@ -449,6 +457,7 @@ walk: // Outer loop for walking the tree
 					}
 
 					handle = n.handlers
+					fullPath = n.fullPath
 					return
 
 				default:
@@ -459,6 +468,7 @@ walk: // Outer loop for walking the tree
 			// We should have reached the node containing the handle.
 			// Check if this node has a handle registered.
 			if handle = n.handlers; handle != nil {
+				fullPath = n.fullPath
 				return
 			}
 